@@ -0,0 +1,340 @@
+package akara
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gravestench/bitset"
+)
+
+// Archetype is a group of entities which all have exactly the same set of components.
+// The components of an archetype are stored column-wise; there is one column per component
+// type, and the row of an entity is the same in every column.
+//
+// Archetypes are only used when the world is created with archetype storage enabled.
+// See WorldConfig.WithArchetypeStorage.
+type Archetype struct {
+	table    *archetypeTable
+	mask     *bitset.BitSet
+	entities []EID
+	columns  map[ComponentID][]Component
+	// subscriptions is the cached list of world subscriptions which allow this archetype.
+	// numChecked is how many of the world subscriptions have been checked against this archetype.
+	subscriptions []*Subscription
+	numChecked    int
+}
+
+// Mask returns a copy of the component bitset shared by all entities of this archetype
+func (a *Archetype) Mask() *bitset.BitSet {
+	a.table.mutex.RLock()
+	defer a.table.mutex.RUnlock()
+
+	return a.mask.Clone()
+}
+
+// Len returns the number of entities in this archetype
+func (a *Archetype) Len() int {
+	a.table.mutex.RLock()
+	defer a.table.mutex.RUnlock()
+
+	return len(a.entities)
+}
+
+// Entities returns the entities of this archetype. The entity at a given index corresponds
+// to the component at the same index of every column.
+//
+// The returned slice is not a copy; it must not be modified, and it is only valid until
+// components are added to or removed from entities.
+func (a *Archetype) Entities() []EID {
+	a.table.mutex.RLock()
+	defer a.table.mutex.RUnlock()
+
+	return a.entities
+}
+
+// Column returns the components of the given component type, one for each entity of this
+// archetype. Yields nil if the archetype does not have the component type.
+//
+// The returned slice is not a copy; it must not be modified, and it is only valid until
+// components are added to or removed from entities.
+func (a *Archetype) Column(id ComponentID) []Component {
+	a.table.mutex.RLock()
+	defer a.table.mutex.RUnlock()
+
+	return a.columns[id]
+}
+
+// archetypeLocation is where an entity is stored within the archetype table
+type archetypeLocation struct {
+	archetype *Archetype
+	row       int
+}
+
+func newArchetypeTable() *archetypeTable {
+	return &archetypeTable{
+		archetypes: make(map[string]*Archetype),
+		order:      make([]*Archetype, 0),
+		locations:  make(map[EID]archetypeLocation),
+		subscribed: make(map[EID]*Archetype),
+	}
+}
+
+// archetypeTable holds all of the archetypes of a world, and where each entity is stored.
+type archetypeTable struct {
+	mutex      sync.RWMutex
+	archetypes map[string]*Archetype // keyed by archetypeKey of the archetype mask
+	order      []*Archetype          // archetypes in order of creation, for deterministic iteration
+	locations  map[EID]archetypeLocation
+	// subscribed is the archetype each entity was in when its subscriptions were last updated.
+	// This is only accessed while the world mutex is locked.
+	subscribed map[EID]*Archetype
+}
+
+// archetypeKey yields a string which uniquely identifies the set bits of the bitset
+func archetypeKey(mask *bitset.BitSet) string {
+	bits := mask.ToIntArray()
+	parts := make([]string, len(bits))
+
+	for idx := range bits {
+		parts[idx] = strconv.FormatUint(bits[idx], 10)
+	}
+
+	return strings.Join(parts, ",")
+}
+
+// archetype yields the archetype for the given mask, creating it if it does not exist
+func (t *archetypeTable) archetype(mask *bitset.BitSet) *Archetype {
+	key := archetypeKey(mask)
+
+	if a, found := t.archetypes[key]; found {
+		return a
+	}
+
+	a := &Archetype{
+		table:    t,
+		mask:     mask,
+		entities: make([]EID, 0),
+		columns:  make(map[ComponentID][]Component),
+	}
+
+	for _, cid := range mask.ToIntArray() {
+		a.columns[ComponentID(cid)] = make([]Component, 0)
+	}
+
+	t.archetypes[key] = a
+	t.order = append(t.order, a)
+
+	return a
+}
+
+// archetypeOf yields the archetype that the entity is currently stored in, or nil
+func (t *archetypeTable) archetypeOf(id EID) *Archetype {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+
+	return t.locations[id].archetype
+}
+
+// move moves the entity into the given archetype, carrying over the components it already
+// has. Components which the destination archetype has, but the entity does not, are nil.
+func (t *archetypeTable) move(id EID, to *Archetype) int {
+	from, found := t.locations[id]
+
+	row := len(to.entities)
+	to.entities = append(to.entities, id)
+
+	for cid := range to.columns {
+		var c Component
+
+		if found {
+			if column, has := from.archetype.columns[cid]; has {
+				c = column[from.row]
+			}
+		}
+
+		to.columns[cid] = append(to.columns[cid], c)
+	}
+
+	if found {
+		t.evict(from)
+	}
+
+	t.locations[id] = archetypeLocation{archetype: to, row: row}
+
+	return row
+}
+
+// evict removes the row from the archetype, moving the last row into the gap
+func (t *archetypeTable) evict(loc archetypeLocation) {
+	a, row := loc.archetype, loc.row
+	last := len(a.entities) - 1
+
+	if row != last {
+		moved := a.entities[last]
+		a.entities[row] = moved
+		t.locations[moved] = archetypeLocation{archetype: a, row: row}
+	}
+
+	a.entities = a.entities[:last]
+
+	for cid, column := range a.columns {
+		column[row] = column[last]
+		column[last] = nil
+		a.columns[cid] = column[:last]
+	}
+}
+
+func (t *archetypeTable) set(id EID, cid ComponentID, c Component) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	loc, found := t.locations[id]
+
+	if found {
+		if column, has := loc.archetype.columns[cid]; has {
+			column[loc.row] = c
+			return
+		}
+	}
+
+	mask := bitset.NewBitSet()
+	if found {
+		mask = loc.archetype.mask.Clone()
+	}
+
+	mask.Set(int(cid), true)
+
+	to := t.archetype(mask)
+	row := t.move(id, to)
+	to.columns[cid][row] = c
+}
+
+func (t *archetypeTable) remove(id EID, cid ComponentID) bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	loc, found := t.locations[id]
+	if !found {
+		return false
+	}
+
+	if _, has := loc.archetype.columns[cid]; !has {
+		return false
+	}
+
+	mask := loc.archetype.mask.Clone()
+	mask.Set(int(cid), false)
+
+	if mask.Empty() {
+		t.evict(loc)
+		delete(t.locations, id)
+
+		return true
+	}
+
+	t.move(id, t.archetype(mask))
+
+	return true
+}
+
+// despawn removes the entity, and all of its components, from the archetype table
+func (t *archetypeTable) despawn(id EID) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if loc, found := t.locations[id]; found {
+		t.evict(loc)
+		delete(t.locations, id)
+	}
+
+	delete(t.subscribed, id)
+}
+
+func (t *archetypeTable) len(cid ComponentID) int {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+
+	count := 0
+
+	for _, a := range t.order {
+		if _, has := a.columns[cid]; has {
+			count += len(a.entities)
+		}
+	}
+
+	return count
+}
+
+func (t *archetypeTable) each(cid ComponentID, fn func(EID, Component) bool) {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+
+	for _, a := range t.order {
+		column, has := a.columns[cid]
+		if !has {
+			continue
+		}
+
+		for row := range column {
+			if !fn(a.entities[row], column[row]) {
+				return
+			}
+		}
+	}
+}
+
+// query yields all archetypes which are allowed by the component filter
+func (t *archetypeTable) query(filter *ComponentFilter) []*Archetype {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+
+	result := make([]*Archetype, 0)
+
+	for _, a := range t.order {
+		if filter.Allow(a.mask) {
+			result = append(result, a)
+		}
+	}
+
+	return result
+}
+
+// archetypeStorage is the storage backend for a single component type, which stores the
+// component instances in the columns of the world's archetype table.
+//
+// Lookups go through the component index instead, so that they take neither the lock of
+// the table, nor wait for entities to move between archetypes.
+type archetypeStorage struct {
+	table *archetypeTable
+	id    ComponentID
+	index componentIndex
+}
+
+func (s *archetypeStorage) get(id EID) (Component, bool) {
+	return s.concurrentGet(id)
+}
+
+func (s *archetypeStorage) concurrentGet(id EID) (Component, bool) {
+	return s.index.load(id)
+}
+
+func (s *archetypeStorage) set(id EID, c Component) {
+	s.table.set(id, s.id, c)
+	s.index.store(id, c)
+}
+
+func (s *archetypeStorage) remove(id EID) bool {
+	// a despawned entity has already been removed from the table, but not from the index
+	s.index.clear(id)
+
+	return s.table.remove(id, s.id)
+}
+
+func (s *archetypeStorage) len() int {
+	return s.table.len(s.id)
+}
+
+func (s *archetypeStorage) each(fn func(EID, Component) bool) {
+	s.table.each(s.id, fn)
+}
@@ -0,0 +1,151 @@
+package akara
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock is the source of time for a World and its Systems. The default Clock uses the
+// time package, but a ManualClock can be used to control the passage of time in tests.
+// See WorldConfig.WithClock.
+type Clock interface {
+	Now() time.Time
+	Since(t time.Time) time.Duration
+	NewTicker(d time.Duration) Ticker
+}
+
+// Ticker delivers ticks at intervals, like a time.Ticker
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+}
+
+// static check that the clocks implement Clock
+var (
+	_ Clock = RealClock{}
+	_ Clock = &ManualClock{}
+)
+
+// RealClock is a Clock which uses the time package
+type RealClock struct{}
+
+// Now returns the current time
+func (RealClock) Now() time.Time {
+	return time.Now()
+}
+
+// Since returns the time elapsed since t
+func (RealClock) Since(t time.Time) time.Duration {
+	return time.Since(t)
+}
+
+// NewTicker returns a Ticker backed by a time.Ticker
+func (RealClock) NewTicker(d time.Duration) Ticker {
+	return &realTicker{Ticker: time.NewTicker(d)}
+}
+
+type realTicker struct {
+	*time.Ticker
+}
+
+func (t *realTicker) C() <-chan time.Time {
+	return t.Ticker.C
+}
+
+// NewManualClock creates a ManualClock, starting at the given time
+func NewManualClock(start time.Time) *ManualClock {
+	return &ManualClock{
+		now:     start,
+		tickers: make([]*manualTicker, 0),
+	}
+}
+
+// ManualClock is a Clock which only moves forward when it is told to. Tickers created by the
+// ManualClock fire when the clock is advanced past their next tick. Like a time.Ticker, a
+// ticker which is not being read from drops ticks.
+type ManualClock struct {
+	now     time.Time
+	tickers []*manualTicker
+	mutex   sync.Mutex
+}
+
+// Now returns the current time of the clock
+func (c *ManualClock) Now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.now
+}
+
+// Since returns the time elapsed since t, according to the clock
+func (c *ManualClock) Since(t time.Time) time.Duration {
+	return c.Now().Sub(t)
+}
+
+// NewTicker returns a Ticker which fires whenever the clock is advanced past its next tick.
+// Like time.NewTicker, the duration must be greater than zero.
+func (c *ManualClock) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("non-positive interval for ManualClock.NewTicker")
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	t := &manualTicker{
+		clock:  c,
+		period: d,
+		next:   c.now.Add(d),
+		c:      make(chan time.Time, 1),
+	}
+
+	c.tickers = append(c.tickers, t)
+
+	return t
+}
+
+// Advance moves the clock forward by the given duration, firing any tickers along the way
+func (c *ManualClock) Advance(d time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.now = c.now.Add(d)
+
+	for _, t := range c.tickers {
+		for !t.next.After(c.now) {
+			select {
+			case t.c <- t.next:
+			default: // the ticker is not being read from, drop the tick
+			}
+
+			t.next = t.next.Add(t.period)
+		}
+	}
+}
+
+func (c *ManualClock) removeTicker(t *manualTicker) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for idx := range c.tickers {
+		if c.tickers[idx] == t {
+			c.tickers = append(c.tickers[:idx], c.tickers[idx+1:]...)
+			return
+		}
+	}
+}
+
+type manualTicker struct {
+	clock  *ManualClock
+	period time.Duration
+	next   time.Time
+	c      chan time.Time
+}
+
+func (t *manualTicker) C() <-chan time.Time {
+	return t.c
+}
+
+func (t *manualTicker) Stop() {
+	t.clock.removeTicker(t)
+}
@@ -0,0 +1,262 @@
+package akara
+
+import (
+	"fmt"
+	"sync"
+)
+
+// commandKind is the kind of structural change recorded by a CommandBuffer
+type commandKind int
+
+const (
+	spawnCommand commandKind = iota
+	despawnCommand
+	addCommand
+	setCommand
+	removeCommand
+)
+
+// command is a structural change recorded by a CommandBuffer
+type command struct {
+	kind      commandKind
+	entity    EID // may be a placeholder, see CommandBuffer.Spawn
+	component ComponentID
+	value     Component // only for setCommand
+}
+
+// CommandSync declares when the commands a System records are applied
+type CommandSync int
+
+const (
+	// SyncOnUpdate applies the commands of the System during the World Update, after the systems
+	// have ticked. The command buffers of the systems are applied in the order of the systems.
+	// This is the default.
+	SyncOnUpdate CommandSync = iota
+	// SyncAfterTick applies the commands of the System as soon as the System has ticked.
+	// With ParallelScheduling, the commands may be applied while other systems are ticking.
+	SyncAfterTick
+)
+
+// NewCommandBuffer creates a command buffer for the given world. The commands are applied when
+// Apply is called. Systems usually use the command buffer of their BaseSystem instead; see
+// BaseSystem.Commands.
+func NewCommandBuffer(w *World) *CommandBuffer {
+	return &CommandBuffer{
+		world:    w,
+		commands: make([]command, 0),
+	}
+}
+
+// CommandBuffer records structural changes to the world, such as adding and removing components,
+// and applies them later, all at once. This makes it safe to make structural changes while
+// iterating over the entities of a subscription, or while systems tick in parallel.
+//
+// A command buffer is safe for concurrent use.
+type CommandBuffer struct {
+	world    *World
+	commands []command
+	mutex    sync.Mutex
+}
+
+func (b *CommandBuffer) record(c command) *CommandBuffer {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.commands = append(b.commands, c)
+
+	return b
+}
+
+// Spawn records the creation of a new entity, and yields a placeholder entity ID for it.
+// The placeholder can be used by the following commands of this buffer, to give the entity
+// its components. The entity is created when the buffer is applied, so that the entity ID's
+// are allocated in the order of the commands, no matter which goroutines recorded them.
+//
+// Placeholders are unique within the world, and never refer to an entity. A placeholder is only
+// valid in the buffer which yielded it, until the buffer is applied.
+func (b *CommandBuffer) Spawn() EID {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	placeholder := b.world.entities.placeholder()
+	b.commands = append(b.commands, command{kind: spawnCommand, entity: placeholder})
+
+	return placeholder
+}
+
+// Despawn records the removal of the entity. See World.RemoveEntity.
+func (b *CommandBuffer) Despawn(id EID) *CommandBuffer {
+	return b.record(command{kind: despawnCommand, entity: id})
+}
+
+// Add records the creation of a component for the entity. See ComponentFactory.Add.
+// The given component only declares the component type, like the components given to
+// a ComponentFilterBuilder. The component type is registered if needed.
+func (b *CommandBuffer) Add(id EID, c Component) *CommandBuffer {
+	return b.record(command{kind: addCommand, entity: id, component: b.world.RegisterComponent(c)})
+}
+
+// Set records setting the component of the entity to the given component instance, replacing
+// any existing component of the same type. The component type is registered if needed.
+func (b *CommandBuffer) Set(id EID, c Component) *CommandBuffer {
+	return b.record(command{kind: setCommand, entity: id, component: b.world.RegisterComponent(c), value: c})
+}
+
+// Remove records the removal of a component from the entity. See ComponentFactory.Remove.
+// The given component only declares the component type, like in Add.
+func (b *CommandBuffer) Remove(id EID, c Component) *CommandBuffer {
+	return b.record(command{kind: removeCommand, entity: id, component: b.world.RegisterComponent(c)})
+}
+
+// Len returns the number of recorded commands
+func (b *CommandBuffer) Len() int {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	return len(b.commands)
+}
+
+// Apply applies the recorded commands, in the order that they were recorded, and empties the
+// buffer. Command buffers are applied one at a time, so that the commands of two buffers are
+// never interleaved.
+//
+// The commands are applied all at once, or not at all. Before anything is changed, every command
+// is checked: its entity must be alive, or spawned by an earlier command of this buffer, and its
+// component type must be registered. If a command fails the check, none of the commands are
+// applied, they are discarded, and an error wrapping ErrInvalidCommand is returned.
+func (b *CommandBuffer) Apply() error {
+	b.mutex.Lock()
+	commands := b.commands
+	b.commands = make([]command, 0)
+	b.mutex.Unlock()
+
+	if len(commands) == 0 {
+		return nil
+	}
+
+	b.world.commandMutex.Lock()
+	defer b.world.commandMutex.Unlock()
+
+	if err := b.validate(commands); err != nil {
+		return err
+	}
+
+	spawned := make(map[EID]EID)
+
+	for _, c := range commands {
+		if c.kind == spawnCommand {
+			spawned[c.entity] = b.world.NewEntity()
+			continue
+		}
+
+		if isPlaceholder(c.entity) {
+			c.entity = spawned[c.entity]
+		}
+
+		if c.kind == despawnCommand {
+			b.world.RemoveEntity(c.entity)
+			continue
+		}
+
+		factory := b.world.GetComponentFactory(c.component)
+
+		switch c.kind {
+		case addCommand:
+			factory.Add(c.entity)
+		case setCommand:
+			factory.set(c.entity, c.value)
+		case removeCommand:
+			factory.Remove(c.entity)
+		}
+	}
+
+	return nil
+}
+
+// validate checks that every command can be applied, without applying any of them
+func (b *CommandBuffer) validate(commands []command) error {
+	spawned := make(map[EID]bool)
+
+	for idx, c := range commands {
+		switch {
+		case c.kind == spawnCommand:
+			spawned[c.entity] = true
+			continue
+		case isPlaceholder(c.entity) && !spawned[c.entity]:
+			return fmt.Errorf("%w: command %d refers to a placeholder which was not spawned by this buffer", ErrInvalidCommand, idx)
+		case !isPlaceholder(c.entity) && !b.world.IsAlive(c.entity):
+			return fmt.Errorf("%w: command %d refers to entity %d, which is not alive", ErrInvalidCommand, idx, c.entity)
+		case c.kind != despawnCommand && b.world.GetComponentFactory(c.component) == nil:
+			return fmt.Errorf("%w: command %d refers to component %d, which is not registered", ErrInvalidCommand, idx, c.component)
+		}
+	}
+
+	return nil
+}
+
+// Commands returns the command buffer of the world. It is applied during World Update,
+// after the command buffers of the systems.
+func (w *World) Commands() *CommandBuffer {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.commands == nil {
+		w.commands = NewCommandBuffer(w)
+	}
+
+	return w.commands
+}
+
+// applyCommands applies the command buffers of the systems, in the order of the systems,
+// and then the command buffer of the world. Buffers which cannot be applied are passed to
+// the error handler of the world.
+func (w *World) applyCommands() {
+	w.mutex.Lock()
+	systems := make([]System, len(w.Systems))
+	copy(systems, w.Systems)
+	worldCommands := w.commands
+	w.mutex.Unlock()
+
+	for _, s := range systems {
+		if baseContainer, ok := s.(hasBaseSystem); ok {
+			if commands := baseContainer.base().commandBuffer(); commands != nil {
+				if err := commands.Apply(); err != nil {
+					w.handleCommandError(s, err)
+				}
+			}
+		}
+	}
+
+	if worldCommands != nil {
+		if err := worldCommands.Apply(); err != nil {
+			w.handleError(err)
+		}
+	}
+}
+
+// handleCommandError passes the error of a command buffer to the error handler of the world,
+// as an error of the system that recorded the commands, if there is one
+func (w *World) handleCommandError(s System, err error) {
+	if s == nil {
+		w.handleError(err)
+		return
+	}
+
+	w.handleError(&SystemError{System: s, Name: s.Name(), Err: err})
+}
+
+// Commands returns the command buffer of this system. The commands are applied according to
+// the CommandSync of the system; see SetCommandSync. Yields nil if the system has not been
+// added to a world.
+func (s *BaseSystem) Commands() *CommandBuffer {
+	return s.commands
+}
+
+// SetCommandSync sets when the commands of this system are applied
+func (s *BaseSystem) SetCommandSync(sync CommandSync) {
+	s.commandSync = sync
+}
+
+func (s *BaseSystem) commandBuffer() *CommandBuffer {
+	return s.commands
+}
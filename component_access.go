@@ -0,0 +1,33 @@
+package akara
+
+import "github.com/gravestench/bitset"
+
+// NewComponentAccess creates an empty component access declaration
+func NewComponentAccess() *ComponentAccess {
+	return &ComponentAccess{
+		Read:  bitset.NewBitSet(),
+		Write: bitset.NewBitSet(),
+	}
+}
+
+// ComponentAccess declares which component types a system reads, and which it writes.
+// The bit indices of the BitSets are component ID's.
+//
+// Two systems conflict if either one writes a component type that the other reads or writes.
+// Systems which do not conflict can safely be ticked in parallel. See ParallelScheduling.
+type ComponentAccess struct {
+	Read  *bitset.BitSet
+	Write *bitset.BitSet
+}
+
+// ConflictsWith returns true if this component access conflicts with the other
+func (a *ComponentAccess) ConflictsWith(other *ComponentAccess) bool {
+	return a.Write.Intersects(other.Write) ||
+		a.Write.Intersects(other.Read) ||
+		a.Read.Intersects(other.Write)
+}
+
+// accessDeclarer describes a System which declares its component access
+type accessDeclarer interface {
+	ComponentAccess() *ComponentAccess
+}
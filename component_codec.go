@@ -0,0 +1,157 @@
+package akara
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// ComponentMarshaler is implemented by components which encode themselves.
+// See ComponentFactory.Encode.
+type ComponentMarshaler interface {
+	MarshalComponent() ([]byte, error)
+}
+
+// ComponentUnmarshaler is implemented by components which decode themselves, from the
+// data of their MarshalComponent method. See ComponentFactory.Decode.
+type ComponentUnmarshaler interface {
+	UnmarshalComponent(data []byte) error
+}
+
+// ComponentCodec encodes and decodes the component instances of a component type.
+// See World.RegisterComponentCodec.
+type ComponentCodec interface {
+	Marshal(c Component) ([]byte, error)
+	Unmarshal(data []byte, c Component) error
+}
+
+// static check that the marshalerCodec implements ComponentCodec
+var _ ComponentCodec = marshalerCodec{}
+
+// marshalerCodec is the codec of components which implement both ComponentMarshaler and
+// ComponentUnmarshaler. Components which implement only one of them are encoded and decoded
+// with the ReflectCodec, so that they can always decode what they encoded.
+type marshalerCodec struct{}
+
+func (marshalerCodec) Marshal(c Component) ([]byte, error) {
+	if m, u := marshalers(c); m != nil && u != nil {
+		return m.MarshalComponent()
+	}
+
+	return ReflectCodec{}.Marshal(c)
+}
+
+func (marshalerCodec) Unmarshal(data []byte, c Component) error {
+	if m, u := marshalers(c); m != nil && u != nil {
+		return u.UnmarshalComponent(data)
+	}
+
+	return ReflectCodec{}.Unmarshal(data, c)
+}
+
+// marshalers yields the value as a ComponentMarshaler and a ComponentUnmarshaler,
+// or nil for whichever one it does not implement
+func marshalers(c interface{}) (ComponentMarshaler, ComponentUnmarshaler) {
+	m, _ := c.(ComponentMarshaler)
+	u, _ := c.(ComponentUnmarshaler)
+
+	return m, u
+}
+
+// componentCodecs is the registry of component codecs, keyed by component type
+type componentCodecs map[reflect.Type]ComponentCodec
+
+// RegisterComponentCodec sets the codec used to encode and decode the component instances
+// of the given component type, which does not need to be registered yet. A nil codec
+// removes the codec of the component type.
+//
+// Component types without a registered codec use their MarshalComponent and
+// UnmarshalComponent methods, if they have both, and otherwise the ReflectCodec.
+func (w *World) RegisterComponentCodec(c Component, codec ComponentCodec) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	t := reflect.TypeOf(c)
+
+	if codec == nil {
+		delete(w.codecs, t)
+		return
+	}
+
+	w.codecs[t] = codec
+}
+
+// ComponentCodec returns the codec used to encode and decode the component instances
+// of the given component ID, or nil if the component ID is not registered
+func (w *World) ComponentCodec(id ComponentID) ComponentCodec {
+	factory := w.GetComponentFactory(id)
+	if factory == nil {
+		return nil
+	}
+
+	codec, _ := factory.codec()
+
+	return codec
+}
+
+// codec yields the codec of the component type, and whether the component type
+// has a codec of its own, rather than the ReflectCodec
+func (cf *ComponentFactory) codec() (codec ComponentCodec, custom bool) {
+	cf.world.mutex.Lock()
+	codec, found := cf.world.codecs[cf.typ]
+	cf.world.mutex.Unlock()
+
+	if found {
+		return codec, true
+	}
+
+	if m, u := marshalers(reflect.Zero(cf.typ).Interface()); m != nil && u != nil {
+		return marshalerCodec{}, true
+	}
+
+	return ReflectCodec{}, false
+}
+
+// Encode encodes the component instance of the given entity ID, using the codec of the
+// component type. See World.RegisterComponentCodec.
+func (cf *ComponentFactory) Encode(id EID) ([]byte, error) {
+	c, found := cf.Get(id)
+	if !found {
+		return nil, fmt.Errorf("entity %d has no %s component", id, cf.Name())
+	}
+
+	codec, _ := cf.codec()
+
+	data, err := codec.Marshal(c)
+	if err != nil {
+		return nil, fmt.Errorf("could not encode %s of entity %d: %w", cf.Name(), id, err)
+	}
+
+	return data, nil
+}
+
+// Decode decodes a component instance, which was encoded with Encode, and sets it as
+// the component instance of the given entity ID. Like Add, this creates the component if
+// the entity does not have it; otherwise the existing instance is replaced, and the
+// component is marked as changed.
+func (cf *ComponentFactory) Decode(id EID, data []byte) error {
+	c, err := cf.decode(data)
+	if err != nil {
+		return fmt.Errorf("could not decode %s of entity %d: %w", cf.Name(), id, err)
+	}
+
+	cf.set(id, c)
+
+	return nil
+}
+
+// decode decodes a new component instance
+func (cf *ComponentFactory) decode(data []byte) (Component, error) {
+	codec, _ := cf.codec()
+	c := cf.provider()
+
+	if err := codec.Unmarshal(data, c); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
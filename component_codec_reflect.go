@@ -0,0 +1,436 @@
+package akara
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"sort"
+)
+
+// static check that the ReflectCodec implements ComponentCodec
+var _ ComponentCodec = ReflectCodec{}
+
+// ReflectCodec is the fallback ComponentCodec. It encodes the exported fields of a component
+// into a compact binary format, using reflection. Unexported fields are ignored.
+//
+// Booleans, numbers, strings, and pointers, slices, arrays, maps, and structs of these are
+// supported. Map entries are ordered by their encoded keys, so that equal components are
+// always encoded to the same bytes. Interfaces, channels, and functions are not supported,
+// and neither are values which refer to themselves, such as cyclic linked lists.
+type ReflectCodec struct{}
+
+// reflectVisit is a pointer, slice, or map which is being encoded. The type is part of the
+// visit, because a pointer to a struct and a pointer to its first field have the same address.
+type reflectVisit struct {
+	ptr uintptr
+	typ reflect.Type
+}
+
+// Marshal encodes the exported fields of the component
+func (ReflectCodec) Marshal(c Component) ([]byte, error) {
+	v := reflect.ValueOf(c)
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil, fmt.Errorf("%w: nil %v", ErrUnsupportedComponent, v.Type())
+		}
+
+		v = v.Elem()
+	}
+
+	buf := &bytes.Buffer{}
+
+	if err := encodeValue(buf, v, make(map[reflectVisit]bool)); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Unmarshal decodes the exported fields of the component, which must be a pointer
+func (ReflectCodec) Unmarshal(data []byte, c Component) error {
+	v := reflect.ValueOf(c)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return fmt.Errorf("%w: cannot decode into %T", ErrUnsupportedComponent, c)
+	}
+
+	r := bytes.NewReader(data)
+
+	if err := decodeValue(r, v.Elem()); err != nil {
+		return err
+	}
+
+	if r.Len() > 0 {
+		return fmt.Errorf("%w: %d bytes left over decoding %T", ErrUnsupportedComponent, r.Len(), c)
+	}
+
+	return nil
+}
+
+// encodeValue encodes the value. The pointers, slices, and maps which are being encoded are
+// kept in visiting, so that a value which refers to itself is reported instead of recursing forever.
+func encodeValue(buf *bytes.Buffer, v reflect.Value, visiting map[reflectVisit]bool) error {
+	var scratch [binary.MaxVarintLen64]byte
+
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Map:
+		if !v.IsNil() && (v.Kind() != reflect.Slice || v.Len() > 0) {
+			visit := reflectVisit{ptr: v.Pointer(), typ: v.Type()}
+			if visiting[visit] {
+				return fmt.Errorf("%w: %v refers to itself", ErrUnsupportedComponent, v.Type())
+			}
+
+			visiting[visit] = true
+			defer delete(visiting, visit)
+		}
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		if v.Bool() {
+			buf.WriteByte(1)
+		} else {
+			buf.WriteByte(0)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		buf.Write(scratch[:binary.PutVarint(scratch[:], v.Int())])
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		buf.Write(scratch[:binary.PutUvarint(scratch[:], v.Uint())])
+	case reflect.Float32:
+		binary.LittleEndian.PutUint32(scratch[:4], math.Float32bits(float32(v.Float())))
+		buf.Write(scratch[:4])
+	case reflect.Float64:
+		binary.LittleEndian.PutUint64(scratch[:8], math.Float64bits(v.Float()))
+		buf.Write(scratch[:8])
+	case reflect.Complex64, reflect.Complex128:
+		size := 4
+		if v.Kind() == reflect.Complex128 {
+			size = 8
+		}
+
+		for _, f := range []float64{real(v.Complex()), imag(v.Complex())} {
+			if size == 4 {
+				binary.LittleEndian.PutUint32(scratch[:4], math.Float32bits(float32(f)))
+			} else {
+				binary.LittleEndian.PutUint64(scratch[:8], math.Float64bits(f))
+			}
+
+			buf.Write(scratch[:size])
+		}
+	case reflect.String:
+		buf.Write(scratch[:binary.PutUvarint(scratch[:], uint64(v.Len()))])
+		buf.WriteString(v.String())
+	case reflect.Ptr:
+		if v.IsNil() {
+			buf.WriteByte(0)
+			return nil
+		}
+
+		buf.WriteByte(1)
+
+		return encodeValue(buf, v.Elem(), visiting)
+	case reflect.Slice:
+		// the length is offset by one, so that a nil slice can be told apart from an empty slice
+		if v.IsNil() {
+			buf.WriteByte(0)
+			return nil
+		}
+
+		buf.Write(scratch[:binary.PutUvarint(scratch[:], uint64(v.Len())+1)])
+
+		return encodeElements(buf, v, visiting)
+	case reflect.Array:
+		return encodeElements(buf, v, visiting)
+	case reflect.Map:
+		if v.IsNil() {
+			buf.WriteByte(0)
+			return nil
+		}
+
+		buf.Write(scratch[:binary.PutUvarint(scratch[:], uint64(v.Len())+1)])
+
+		return encodeMap(buf, v, visiting)
+	case reflect.Struct:
+		for idx := 0; idx < v.NumField(); idx++ {
+			if !v.Type().Field(idx).IsExported() {
+				continue
+			}
+
+			if err := encodeValue(buf, v.Field(idx), visiting); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("%w: cannot encode %v", ErrUnsupportedComponent, v.Type())
+	}
+
+	return nil
+}
+
+func encodeElements(buf *bytes.Buffer, v reflect.Value, visiting map[reflectVisit]bool) error {
+	if v.Type().Elem().Kind() == reflect.Uint8 && v.Kind() == reflect.Slice {
+		buf.Write(v.Bytes())
+		return nil
+	}
+
+	for idx := 0; idx < v.Len(); idx++ {
+		if err := encodeValue(buf, v.Index(idx), visiting); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// encodeMap encodes the entries of the map, ordered by their encoded keys
+func encodeMap(buf *bytes.Buffer, v reflect.Value, visiting map[reflectVisit]bool) error {
+	type entry struct {
+		key   []byte
+		value reflect.Value
+	}
+
+	entries := make([]entry, 0, v.Len())
+	iter := v.MapRange()
+
+	for iter.Next() {
+		keyBuf := &bytes.Buffer{}
+		if err := encodeValue(keyBuf, iter.Key(), visiting); err != nil {
+			return err
+		}
+
+		entries = append(entries, entry{key: keyBuf.Bytes(), value: iter.Value()})
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return bytes.Compare(entries[i].key, entries[j].key) < 0
+	})
+
+	for _, e := range entries {
+		buf.Write(e.key)
+
+		if err := encodeValue(buf, e.value, visiting); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func decodeValue(r *bytes.Reader, v reflect.Value) error {
+	switch v.Kind() {
+	case reflect.Bool:
+		b, err := r.ReadByte()
+		if err != nil {
+			return decodeErr(v, err)
+		}
+
+		v.SetBool(b != 0)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := binary.ReadVarint(r)
+		if err != nil {
+			return decodeErr(v, err)
+		}
+
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n, err := binary.ReadUvarint(r)
+		if err != nil {
+			return decodeErr(v, err)
+		}
+
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := decodeFloat(r, v.Kind() == reflect.Float64)
+		if err != nil {
+			return decodeErr(v, err)
+		}
+
+		v.SetFloat(f)
+	case reflect.Complex64, reflect.Complex128:
+		re, err := decodeFloat(r, v.Kind() == reflect.Complex128)
+		if err != nil {
+			return decodeErr(v, err)
+		}
+
+		im, err := decodeFloat(r, v.Kind() == reflect.Complex128)
+		if err != nil {
+			return decodeErr(v, err)
+		}
+
+		v.SetComplex(complex(re, im))
+	case reflect.String:
+		data, err := decodeBytes(r)
+		if err != nil {
+			return decodeErr(v, err)
+		}
+
+		v.SetString(string(data))
+	case reflect.Ptr:
+		present, err := r.ReadByte()
+		if err != nil {
+			return decodeErr(v, err)
+		}
+
+		if present == 0 {
+			v.Set(reflect.Zero(v.Type()))
+			return nil
+		}
+
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+
+		return decodeValue(r, v.Elem())
+	case reflect.Slice:
+		n, err := decodeLength(r, encodesNothing(v.Type().Elem()))
+		if err != nil || n < 0 {
+			v.Set(reflect.Zero(v.Type()))
+			return decodeErr(v, err)
+		}
+
+		v.Set(reflect.MakeSlice(v.Type(), n, n))
+
+		return decodeElements(r, v)
+	case reflect.Array:
+		return decodeElements(r, v)
+	case reflect.Map:
+		n, err := decodeLength(r, encodesNothing(v.Type().Key()) && encodesNothing(v.Type().Elem()))
+		if err != nil || n < 0 {
+			v.Set(reflect.Zero(v.Type()))
+			return decodeErr(v, err)
+		}
+
+		m := reflect.MakeMapWithSize(v.Type(), n)
+
+		for idx := 0; idx < n; idx++ {
+			key := reflect.New(v.Type().Key()).Elem()
+			if err := decodeValue(r, key); err != nil {
+				return err
+			}
+
+			value := reflect.New(v.Type().Elem()).Elem()
+			if err := decodeValue(r, value); err != nil {
+				return err
+			}
+
+			m.SetMapIndex(key, value)
+		}
+
+		v.Set(m)
+	case reflect.Struct:
+		for idx := 0; idx < v.NumField(); idx++ {
+			if !v.Type().Field(idx).IsExported() {
+				continue
+			}
+
+			if err := decodeValue(r, v.Field(idx)); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("%w: cannot decode %v", ErrUnsupportedComponent, v.Type())
+	}
+
+	return nil
+}
+
+func decodeElements(r *bytes.Reader, v reflect.Value) error {
+	if v.Type().Elem().Kind() == reflect.Uint8 && v.Kind() == reflect.Slice {
+		if _, err := io.ReadFull(r, v.Bytes()); err != nil {
+			return decodeErr(v, err)
+		}
+
+		return nil
+	}
+
+	for idx := 0; idx < v.Len(); idx++ {
+		if err := decodeValue(r, v.Index(idx)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// decodeLength decodes the length of a slice or map, which is -1 for nil. Unless the
+// elements are encoded as nothing, every element takes at least one byte, which is
+// used to detect corrupt lengths.
+func decodeLength(r *bytes.Reader, emptyElements bool) (int, error) {
+	n, err := binary.ReadUvarint(r)
+	if err != nil {
+		return 0, err
+	}
+
+	if n > math.MaxInt32 || (!emptyElements && n > uint64(r.Len())+1) {
+		return 0, io.ErrUnexpectedEOF
+	}
+
+	return int(n) - 1, nil
+}
+
+// encodesNothing returns true if the values of the type are encoded as zero bytes, like
+// empty structs, structs without exported fields, and arrays of length zero
+func encodesNothing(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Struct:
+		for idx := 0; idx < t.NumField(); idx++ {
+			if t.Field(idx).IsExported() && !encodesNothing(t.Field(idx).Type) {
+				return false
+			}
+		}
+
+		return true
+	case reflect.Array:
+		return t.Len() == 0 || encodesNothing(t.Elem())
+	default:
+		return false
+	}
+}
+
+func decodeBytes(r *bytes.Reader) ([]byte, error) {
+	n, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if n > uint64(r.Len()) {
+		return nil, io.ErrUnexpectedEOF
+	}
+
+	data := make([]byte, n)
+	_, err = io.ReadFull(r, data)
+
+	return data, err
+}
+
+func decodeFloat(r *bytes.Reader, double bool) (float64, error) {
+	if !double {
+		var bits [4]byte
+		if _, err := io.ReadFull(r, bits[:]); err != nil {
+			return 0, err
+		}
+
+		return float64(math.Float32frombits(binary.LittleEndian.Uint32(bits[:]))), nil
+	}
+
+	var bits [8]byte
+	if _, err := io.ReadFull(r, bits[:]); err != nil {
+		return 0, err
+	}
+
+	return math.Float64frombits(binary.LittleEndian.Uint64(bits[:])), nil
+}
+
+func decodeErr(v reflect.Value, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+
+	return fmt.Errorf("could not decode %v: %w", v.Type(), err)
+}
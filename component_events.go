@@ -0,0 +1,175 @@
+package akara
+
+import "sync"
+
+// ComponentEventKind is the kind of change to a component instance
+type ComponentEventKind int
+
+const (
+	// ComponentAdded is the creation of a component instance for an entity
+	ComponentAdded ComponentEventKind = iota
+	// ComponentRemoved is the destruction of a component instance, including when
+	// the entity is removed from the world
+	ComponentRemoved
+	// ComponentChanged is a change to a component instance. This is only emitted when
+	// the change is reported explicitly with ComponentFactory.MarkChanged.
+	ComponentChanged
+)
+
+// String returns the name of the kind of change
+func (k ComponentEventKind) String() string {
+	switch k {
+	case ComponentAdded:
+		return "Added"
+	case ComponentRemoved:
+		return "Removed"
+	case ComponentChanged:
+		return "Changed"
+	default:
+		return "Unknown"
+	}
+}
+
+// ComponentEvent is a change to the component instance of an entity
+type ComponentEvent struct {
+	Kind      ComponentEventKind
+	Entity    EID
+	Component ComponentID
+}
+
+// EventDelivery declares when an observer receives component events
+type EventDelivery int
+
+const (
+	// ImmediateDelivery calls the observer as soon as the change happens, on the goroutine
+	// which made the change
+	ImmediateDelivery EventDelivery = iota
+	// DeferredDelivery queues the events, and calls the observer during the next World Update,
+	// after queued systems and entities have been removed
+	DeferredDelivery
+)
+
+// componentObservers holds the observers of a component factory, or of the whole world.
+// The queue of deferred events is only used by the world.
+type componentObservers struct {
+	mutex     sync.Mutex
+	immediate []func(ComponentEvent)
+	deferred  []func(ComponentEvent)
+	queue     []ComponentEvent
+}
+
+func (o *componentObservers) add(delivery EventDelivery, fn func(ComponentEvent)) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	if delivery == DeferredDelivery {
+		o.deferred = append(o.deferred, fn)
+		return
+	}
+
+	o.immediate = append(o.immediate, fn)
+}
+
+// observers yields the observers with the given delivery, and whether there are any
+func (o *componentObservers) observers(delivery EventDelivery) ([]func(ComponentEvent), bool) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	if delivery == DeferredDelivery {
+		return o.deferred, len(o.deferred) > 0
+	}
+
+	return o.immediate, len(o.immediate) > 0
+}
+
+func (o *componentObservers) enqueue(event ComponentEvent) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	o.queue = append(o.queue, event)
+}
+
+func (o *componentObservers) drain() []ComponentEvent {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	queue := o.queue
+	o.queue = nil
+
+	return queue
+}
+
+// Observe adds an observer, which receives an event whenever a component instance of this
+// factory is added, removed, or marked as changed. See EventDelivery.
+//
+// Immediate observers must not make changes to this component factory.
+func (cf *ComponentFactory) Observe(delivery EventDelivery, fn func(ComponentEvent)) {
+	cf.observers.add(delivery, fn)
+}
+
+// MarkChanged reports that the component instance of the entity has been changed. The component
+// is stamped with the current change tick, and the observers are notified with a ComponentChanged
+// event. Nothing happens if the entity does not have a component instance in this factory.
+func (cf *ComponentFactory) MarkChanged(id EID) {
+	cf.mux.Lock()
+	_, found := cf.storage.get(id)
+	if found {
+		ticks := cf.ticks[id]
+		ticks.changed = cf.world.ChangeTick()
+		cf.ticks[id] = ticks
+	}
+	cf.mux.Unlock()
+
+	if found {
+		cf.emit(ComponentChanged, id)
+	}
+}
+
+// emit notifies the immediate observers of the factory and the world, and queues the event
+// for the deferred observers. Neither the factory nor the world may be locked by the caller.
+func (cf *ComponentFactory) emit(kind ComponentEventKind, id EID) {
+	event := ComponentEvent{Kind: kind, Entity: id, Component: cf.id}
+	world := cf.world.componentObservers
+
+	for _, observers := range []*componentObservers{&cf.observers, world} {
+		if fns, found := observers.observers(ImmediateDelivery); found {
+			for _, fn := range fns {
+				fn(event)
+			}
+		}
+	}
+
+	_, factoryDefers := cf.observers.observers(DeferredDelivery)
+	_, worldDefers := world.observers(DeferredDelivery)
+
+	if factoryDefers || worldDefers {
+		world.enqueue(event)
+	}
+}
+
+// ObserveComponents adds an observer, which receives an event whenever a component instance
+// of any component factory is added, removed, or marked as changed. See EventDelivery.
+func (w *World) ObserveComponents(delivery EventDelivery, fn func(ComponentEvent)) {
+	w.componentObservers.add(delivery, fn)
+}
+
+// flushComponentEvents delivers the queued component events to the deferred observers
+// of the component factories and the world, in the order that the events happened
+func (w *World) flushComponentEvents() {
+	events := w.componentObservers.drain()
+	worldObservers, _ := w.componentObservers.observers(DeferredDelivery)
+
+	for _, event := range events {
+		if factory := w.GetComponentFactory(event.Component); factory != nil {
+			factoryObservers, _ := factory.observers.observers(DeferredDelivery)
+
+			for _, fn := range factoryObservers {
+				fn(event)
+			}
+		}
+
+		for _, fn := range worldObservers {
+			fn(event)
+		}
+	}
+}
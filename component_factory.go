@@ -1,14 +1,22 @@
 package akara
 
-import "sync"
+import (
+	"reflect"
+	"sync"
+	"sync/atomic"
+)
 
-func newComponentFactory(id ComponentID) *ComponentFactory {
+func newComponentFactory(w *World, id ComponentID) *ComponentFactory {
 	cf := &ComponentFactory{
-		id:        id,
-		mux:       &sync.Mutex{},
-		instances: make(map[EID]Component),
+		world: w,
+		id:    id,
+		mux:   &sync.RWMutex{},
+		ticks: make(map[EID]componentTicks),
 	}
 
+	cf.kind, cf.storage = newComponentStorage(w, id, MapStorage)
+	cf.publishStorage()
+
 	return cf
 }
 
@@ -21,9 +29,17 @@ func newComponentFactory(id ComponentID) *ComponentFactory {
 type ComponentFactory struct {
 	world     *World
 	id        ComponentID
-	instances map[EID]Component
+	typ       reflect.Type
+	name      string
+	named     bool // true if the name was given explicitly
+	kind      StorageKind
+	storage   componentStorage
+	lockFree  atomic.Value // lockFreeStorage, published whenever the storage changes
 	provider  func() Component
-	mux       *sync.Mutex
+	mux       *sync.RWMutex
+	observers componentObservers
+	ticks     map[EID]componentTicks
+	networked bool
 }
 
 // ID returns the registered component ID for this component type
@@ -31,52 +47,200 @@ func (cf *ComponentFactory) ID() ComponentID {
 	return cf.id
 }
 
-func (cf *ComponentFactory) factoryNew(id EID) Component {
-	cf.instances[id] = cf.provider()
+// Name returns the registered name of this component type
+func (cf *ComponentFactory) Name() string {
+	return cf.name
+}
+
+// StorageKind returns the kind of storage backend used by this component factory
+func (cf *ComponentFactory) StorageKind() StorageKind {
+	cf.mux.RLock()
+	defer cf.mux.RUnlock()
 
-	return cf.instances[id]
+	return cf.kind
+}
+
+// SetStorage changes the storage backend used by this component factory. Any existing
+// component instances are moved into the new storage backend.
+// When the world uses archetype storage, the storage backend cannot be changed.
+func (cf *ComponentFactory) SetStorage(kind StorageKind) {
+	cf.mux.Lock()
+	defer cf.mux.Unlock()
+
+	if kind == cf.kind {
+		return
+	}
+
+	kind, storage := newComponentStorage(cf.world, cf.id, kind)
+	if kind == cf.kind {
+		return
+	}
+
+	cf.storage.each(func(id EID, c Component) bool {
+		storage.set(id, c)
+		return true
+	})
+
+	cf.kind, cf.storage = kind, storage
+	cf.publishStorage()
 }
 
 // Add a new component for the given entity ID and yield the component.
 // If a component already exists, yield the existing component.
+// If the entity ID is stale, nothing is created and nil is yielded.
 //
-// This operation will update world subscriptions for the given entity.
+// This operation will update world subscriptions for the given entity, and notify the observers
+// if the component was created.
 func (cf *ComponentFactory) Add(id EID) Component {
-	if c, found := cf.Get(id); found {
-		return c
+	if !cf.world.IsAlive(id) {
+		return nil
 	}
 
 	cf.mux.Lock()
 
-	c := cf.factoryNew(id)
+	c, found := cf.storage.get(id)
+	if !found {
+		c = cf.provider()
+		cf.storage.set(id, c)
+
+		tick := cf.world.ChangeTick()
+		cf.ticks[id] = componentTicks{added: tick, changed: tick}
+	}
 
 	cf.mux.Unlock()
 
-	cf.world.UpdateEntity(id)
+	if !found {
+		cf.world.UpdateEntity(id)
+		cf.emit(ComponentAdded, id)
+	}
 
 	return c
 }
 
 // Get will yield the component and a bool, much like map retrieval.
 // The bool indicates whether a component was found for the given entity ID.
-// The component can be nil.
+// The component can be nil. Stale entity ID's never yield a component, because
+// the components of an entity are destroyed when the entity is removed.
+//
+// With SparseSetStorage and ArchetypeStorage, Get takes no locks, so it does not wait for
+// other goroutines which are adding or removing components.
 func (cf *ComponentFactory) Get(id EID) (Component, bool) {
-	cf.mux.Lock()
-	defer cf.mux.Unlock()
+	if s, _ := cf.lockFree.Load().(lockFreeStorage); s.storage != nil {
+		return s.storage.concurrentGet(id)
+	}
 
-	c, found := cf.instances[id]
+	cf.mux.RLock()
+	defer cf.mux.RUnlock()
+
+	return cf.storage.get(id)
+}
 
-	return c, found
+// lockFreeStorage holds the storage backend of a ComponentFactory if its lookups need no lock,
+// and nil otherwise
+type lockFreeStorage struct {
+	storage concurrentStorage
+}
+
+// publishStorage publishes the storage backend for the lookups which take no lock.
+// The factory must be write-locked, or not yet shared.
+func (cf *ComponentFactory) publishStorage() {
+	s, _ := cf.storage.(concurrentStorage)
+	cf.lockFree.Store(lockFreeStorage{storage: s})
 }
 
 // Remove will destroy the component instance for the given entity ID.
-// This operation will update world subscriptions for the given entity ID.
+// This operation will update world subscriptions for the given entity ID, and notify the observers
+// if a component was removed.
 func (cf *ComponentFactory) Remove(id EID) {
 	cf.mux.Lock()
 
-	delete(cf.instances, id)
+	removed := cf.storage.remove(id)
+	delete(cf.ticks, id)
 
 	cf.mux.Unlock()
 
 	cf.world.UpdateEntity(id)
+
+	if removed {
+		cf.emit(ComponentRemoved, id)
+	}
+}
+
+// Len returns the number of component instances in this component factory
+func (cf *ComponentFactory) Len() int {
+	cf.mux.RLock()
+	defer cf.mux.RUnlock()
+
+	return cf.storage.len()
+}
+
+// Each calls fn for every entity which has a component in this factory, until fn returns false.
+// With SparseSetStorage, this walks the dense array of components in order.
+//
+// The factory is read-locked during iteration, so fn must not call any methods of this
+// component factory. To make changes, collect the entity ID's and make the changes afterwards.
+func (cf *ComponentFactory) Each(fn func(EID, Component) bool) {
+	cf.mux.RLock()
+	defer cf.mux.RUnlock()
+
+	cf.storage.each(fn)
+}
+
+// set sets the component instance of the entity, replacing any existing instance.
+// This operation will update world subscriptions for the given entity if the component was
+// created, and notify the observers.
+func (cf *ComponentFactory) set(id EID, c Component) {
+	if !cf.world.IsAlive(id) {
+		return
+	}
+
+	cf.mux.Lock()
+
+	_, found := cf.storage.get(id)
+	cf.storage.set(id, c)
+
+	tick := cf.world.ChangeTick()
+	ticks := cf.ticks[id]
+	if !found {
+		ticks.added = tick
+	}
+	ticks.changed = tick
+	cf.ticks[id] = ticks
+
+	cf.mux.Unlock()
+
+	if found {
+		cf.emit(ComponentChanged, id)
+		return
+	}
+
+	cf.world.UpdateEntity(id)
+	cf.emit(ComponentAdded, id)
+}
+
+// release destroys the component instance for the given entity ID, without
+// updating world subscriptions. This is used when the entity itself is removed.
+// Returns true if a component was destroyed.
+func (cf *ComponentFactory) release(id EID) bool {
+	cf.mux.Lock()
+	defer cf.mux.Unlock()
+
+	delete(cf.ticks, id)
+
+	return cf.storage.remove(id)
+}
+
+// entityIDs yields the entity ID's of all entities which have a component in this factory
+func (cf *ComponentFactory) entityIDs() []EID {
+	cf.mux.RLock()
+	defer cf.mux.RUnlock()
+
+	ids := make([]EID, 0, cf.storage.len())
+
+	cf.storage.each(func(id EID, _ Component) bool {
+		ids = append(ids, id)
+		return true
+	})
+
+	return ids
 }
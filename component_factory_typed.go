@@ -0,0 +1,76 @@
+package akara
+
+// componentPointer describes a pointer to T which implements the Component interface.
+// This allows a typed factory to be declared using the component struct type, even though
+// it is the pointer type which implements Component.
+type componentPointer[T any] interface {
+	*T
+	Component
+}
+
+// Register registers the component type T in the given world, and yields a typed
+// component factory for it. The pointer type *T must implement the Component interface.
+//
+// Registering the same component type more than once yields factories which share the
+// same underlying ComponentFactory.
+//
+// Example:
+//
+//	velocities := akara.Register[Velocity](world)
+//	v := velocities.Add(e) // v is a *Velocity
+func Register[T any, P componentPointer[T]](w *World) *Factory[T] {
+	id := w.RegisterComponent(P(new(T)))
+
+	return &Factory[T]{ComponentFactory: w.GetComponentFactory(id)}
+}
+
+// Factory is a typed wrapper for a ComponentFactory. It yields component instances
+// of type *T, so that the returned components do not need to be cast.
+type Factory[T any] struct {
+	*ComponentFactory
+}
+
+// Add a new component for the given entity ID and yield the component.
+// If a component already exists, yield the existing component.
+// If the entity ID is stale, nil is yielded.
+func (f *Factory[T]) Add(id EID) *T {
+	// the assertion goes through interface{}, because the compiler cannot prove that *T implements Component
+	t, _ := interface{}(f.ComponentFactory.Add(id)).(*T)
+
+	return t
+}
+
+// Get will yield the component and a bool, much like map retrieval.
+// The bool indicates whether a component was found for the given entity ID.
+func (f *Factory[T]) Get(id EID) (*T, bool) {
+	c, found := f.ComponentFactory.Get(id)
+	if !found {
+		return nil, false
+	}
+
+	t, ok := interface{}(c).(*T)
+
+	return t, ok
+}
+
+// GetMut will yield the component and a bool, like Get, and marks the component as changed.
+// See ComponentFactory.MarkChanged.
+func (f *Factory[T]) GetMut(id EID) (*T, bool) {
+	c, found := f.ComponentFactory.GetMut(id)
+	if !found {
+		return nil, false
+	}
+
+	t, ok := interface{}(c).(*T)
+
+	return t, ok
+}
+
+// Each calls fn for every entity which has a component in this factory, until fn returns false.
+// See ComponentFactory.Each for the restrictions on what fn may do.
+func (f *Factory[T]) Each(fn func(EID, *T) bool) {
+	f.ComponentFactory.Each(func(id EID, c Component) bool {
+		t, _ := interface{}(c).(*T)
+		return fn(id, t)
+	})
+}
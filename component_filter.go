@@ -14,9 +14,9 @@ import "github.com/gravestench/bitset"
 // pass through the filter.
 func NewComponentFilter(all, oneOf, none *bitset.BitSet) *ComponentFilter {
 	return &ComponentFilter{
-		all,
-		oneOf,
-		none,
+		Required:    all,
+		OneRequired: oneOf,
+		Forbidden:   none,
 	}
 }
 
@@ -30,17 +30,39 @@ func NewComponentFilter(all, oneOf, none *bitset.BitSet) *ComponentFilter {
 //
 // If the target bitset invalidates any of these three rules, the target
 // bitset is said to have been "rejected" by the filter.
+//
+// The Added and Changed BitSets do not affect which bitsets pass through the filter, but they
+// declare which components must have been added or changed for an entity to be returned by
+// Subscription.GetEntitiesSince. Components which are Added or Changed are also Required.
 type ComponentFilter struct {
 	Required    *bitset.BitSet
 	OneRequired *bitset.BitSet
 	Forbidden   *bitset.BitSet
+	Added       *bitset.BitSet
+	Changed     *bitset.BitSet
 }
 
 // Equals checks if this component filter is equal to the argument component filter
 func (cf *ComponentFilter) Equals(other *ComponentFilter) bool {
 	return cf.Required.Equals(other.Required) &&
 		cf.OneRequired.Equals(other.OneRequired) &&
-		cf.Forbidden.Equals(other.Forbidden)
+		cf.Forbidden.Equals(other.Forbidden) &&
+		bitsEqual(cf.Added, other.Added) &&
+		bitsEqual(cf.Changed, other.Changed)
+}
+
+// detectsChanges returns true if the filter declares Added or Changed components
+func (cf *ComponentFilter) detectsChanges() bool {
+	return cf.Added != nil && !cf.Added.Empty() || cf.Changed != nil && !cf.Changed.Empty()
+}
+
+// bitsEqual compares bitsets which may be nil. A nil bitset is equal to an empty bitset.
+func bitsEqual(a, b *bitset.BitSet) bool {
+	if a == nil || b == nil {
+		return (a == nil || a.Empty()) && (b == nil || b.Empty())
+	}
+
+	return a.Equals(b)
 }
 
 // Allow returns true if the given bitset is not rejected by the component filter
@@ -10,6 +10,8 @@ func NewComponentFilterBuilder(w *World) *ComponentFilterBuilder {
 		require:    make([]Component, 0),
 		requireOne: make([]Component, 0),
 		forbid:     make([]Component, 0),
+		added:      make([]Component, 0),
+		changed:    make([]Component, 0),
 	}
 }
 
@@ -19,6 +21,8 @@ type ComponentFilterBuilder struct {
 	require    []Component
 	requireOne []Component
 	forbid     []Component
+	added      []Component
+	changed    []Component
 }
 
 // Build iterates through all components in the filter and registers them in the world,
@@ -49,6 +53,28 @@ func (cfb *ComponentFilterBuilder) Build() *ComponentFilter {
 		f.Forbidden.Set(int(componentID), true)
 	}
 
+	if len(cfb.added) > 0 {
+		f.Added = bitset.NewBitSet()
+	}
+
+	for idx := range cfb.added {
+		componentID := cfb.world.RegisterComponent(cfb.added[idx])
+
+		f.Required.Set(int(componentID), true)
+		f.Added.Set(int(componentID), true)
+	}
+
+	if len(cfb.changed) > 0 {
+		f.Changed = bitset.NewBitSet()
+	}
+
+	for idx := range cfb.changed {
+		componentID := cfb.world.RegisterComponent(cfb.changed[idx])
+
+		f.Required.Set(int(componentID), true)
+		f.Changed.Set(int(componentID), true)
+	}
+
 	return f
 }
 
@@ -78,3 +104,19 @@ func (cfb *ComponentFilterBuilder) Forbid(components ...Component) *ComponentFil
 
 	return cfb
 }
+
+// Added makes all of the given components required, and makes Subscription.GetEntitiesSince
+// only return the entities whose components were added since the given change tick
+func (cfb *ComponentFilterBuilder) Added(components ...Component) *ComponentFilterBuilder {
+	cfb.added = append(cfb.added, components...)
+
+	return cfb
+}
+
+// Changed makes all of the given components required, and makes Subscription.GetEntitiesSince
+// only return the entities whose components were added or changed since the given change tick
+func (cfb *ComponentFilterBuilder) Changed(components ...Component) *ComponentFilterBuilder {
+	cfb.changed = append(cfb.changed, components...)
+
+	return cfb
+}
@@ -0,0 +1,17 @@
+package akara
+
+import "reflect"
+
+// componentTypeName yields the default name of a component type, which is the package path
+// and name of the type. Pointer types are named after the type that they point to.
+func componentTypeName(t reflect.Type) string {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t.Name() == "" {
+		return t.String() // unnamed types, like anonymous structs
+	}
+
+	return t.PkgPath() + "." + t.Name()
+}
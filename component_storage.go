@@ -0,0 +1,84 @@
+package akara
+
+// StorageKind declares which storage backend a ComponentFactory uses for its component instances
+type StorageKind int
+
+const (
+	// MapStorage stores component instances in a map, keyed by entity ID. This is the default.
+	MapStorage StorageKind = iota
+	// SparseSetStorage stores component instances in a packed, dense array, which is indexed
+	// through a sparse array of entity indices. Lookups do not hash, nor lock, and iteration
+	// walks the dense array directly.
+	SparseSetStorage
+	// ArchetypeStorage stores component instances column-wise, in the archetypes of the world.
+	// Lookups do not lock, like those of SparseSetStorage.
+	// This is the only kind of storage used when the world has archetype storage enabled,
+	// and it cannot be used otherwise. See WorldConfig.WithArchetypeStorage.
+	ArchetypeStorage
+)
+
+// componentStorage is a storage backend for the component instances of a ComponentFactory.
+// Storage backends are not thread safe; the ComponentFactory guards access to them.
+type componentStorage interface {
+	get(id EID) (Component, bool)
+	set(id EID, c Component)
+	remove(id EID) bool
+	len() int
+	each(fn func(EID, Component) bool)
+}
+
+// concurrentStorage is implemented by the storage backends whose lookups are safe while
+// another goroutine changes the storage. The ComponentFactory does not lock for these lookups.
+type concurrentStorage interface {
+	concurrentGet(id EID) (Component, bool)
+}
+
+// newComponentStorage creates the storage backend for the component type. If the world uses
+// archetype storage, the requested kind is ignored. Yields the kind of storage that was created.
+func newComponentStorage(w *World, id ComponentID, kind StorageKind) (StorageKind, componentStorage) {
+	if w != nil && w.archetypes != nil {
+		return ArchetypeStorage, &archetypeStorage{table: w.archetypes, id: id}
+	}
+
+	switch kind {
+	case SparseSetStorage:
+		return SparseSetStorage, newSparseSetStorage()
+	default:
+		return MapStorage, make(mapStorage)
+	}
+}
+
+// mapStorage stores component instances in a map, keyed by entity ID
+type mapStorage map[EID]Component
+
+func (m mapStorage) get(id EID) (Component, bool) {
+	c, found := m[id]
+
+	return c, found
+}
+
+func (m mapStorage) set(id EID, c Component) {
+	m[id] = c
+}
+
+func (m mapStorage) remove(id EID) bool {
+	if _, found := m[id]; !found {
+		return false
+	}
+
+	delete(m, id)
+
+	return true
+}
+
+func (m mapStorage) len() int {
+	return len(m)
+}
+
+func (m mapStorage) each(fn func(EID, Component) bool) {
+	for id, c := range m {
+		if !fn(id, c) {
+			return
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package akara
+
+import "sync/atomic"
+
+// componentIndex maps entity indices (see EntityIndex) to component instances.
+//
+// Lookups take no locks, and are safe while another goroutine changes the index.
+// Changes are not thread safe; the ComponentFactory serializes them with its lock.
+//
+// The slots are published atomically, and every slot holds an immutable entry, so a
+// lookup sees either the entry before a change or the entry after it.
+type componentIndex struct {
+	slots atomic.Value // []*componentSlot, replaced when the index grows
+}
+
+type componentSlot struct {
+	entry atomic.Value // *componentEntry, nil if the entity index has no component
+}
+
+type componentEntry struct {
+	id EID
+	c  Component
+}
+
+func (x *componentIndex) load(id EID) (Component, bool) {
+	slots, _ := x.slots.Load().([]*componentSlot)
+	index := int(EntityIndex(id))
+
+	if index >= len(slots) {
+		return nil, false
+	}
+
+	entry, _ := slots[index].entry.Load().(*componentEntry)
+
+	// the entity index may be occupied by a different generation of the entity
+	if entry == nil || entry.id != id {
+		return nil, false
+	}
+
+	return entry.c, true
+}
+
+func (x *componentIndex) store(id EID, c Component) {
+	slots, _ := x.slots.Load().([]*componentSlot)
+	index := int(EntityIndex(id))
+
+	if index >= len(slots) {
+		grown := make([]*componentSlot, 2*(index+1))
+		copy(grown, slots)
+
+		for i := len(slots); i < len(grown); i++ {
+			grown[i] = &componentSlot{}
+		}
+
+		x.slots.Store(grown)
+		slots = grown
+	}
+
+	slots[index].entry.Store(&componentEntry{id: id, c: c})
+}
+
+// clear removes the component of the entity, if the entity index holds one for this generation
+func (x *componentIndex) clear(id EID) {
+	slots, _ := x.slots.Load().([]*componentSlot)
+	index := int(EntityIndex(id))
+
+	if index >= len(slots) {
+		return
+	}
+
+	if entry, _ := slots[index].entry.Load().(*componentEntry); entry != nil && entry.id == id {
+		slots[index].entry.Store((*componentEntry)(nil))
+	}
+}
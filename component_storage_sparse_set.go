@@ -0,0 +1,111 @@
+package akara
+
+func newSparseSetStorage() *sparseSetStorage {
+	return &sparseSetStorage{
+		sparse:     make([]uint32, 0),
+		entities:   make([]EID, 0),
+		components: make([]Component, 0),
+	}
+}
+
+// sparseSetStorage stores component instances in a packed, dense array.
+//
+// The sparse array is indexed by entity index (see EntityIndex), and holds the position
+// of the entity in the dense arrays, plus one. A zero in the sparse array means
+// that the entity index has no component.
+//
+// The dense arrays hold the entity ID's and their components, with no gaps in between.
+// Removing a component moves the last component into the gap.
+//
+// Lookups go through the component index instead, so that they take no locks.
+type sparseSetStorage struct {
+	sparse     []uint32
+	entities   []EID
+	components []Component
+	index      componentIndex
+}
+
+// position yields the position of the entity in the dense arrays
+func (s *sparseSetStorage) position(id EID) (int, bool) {
+	index := int(EntityIndex(id))
+
+	if index >= len(s.sparse) || s.sparse[index] == 0 {
+		return 0, false
+	}
+
+	pos := int(s.sparse[index] - 1)
+
+	// the entity index may be occupied by a different generation of the entity
+	return pos, s.entities[pos] == id
+}
+
+func (s *sparseSetStorage) get(id EID) (Component, bool) {
+	return s.concurrentGet(id)
+}
+
+func (s *sparseSetStorage) concurrentGet(id EID) (Component, bool) {
+	return s.index.load(id)
+}
+
+func (s *sparseSetStorage) set(id EID, c Component) {
+	s.index.store(id, c)
+
+	index := int(EntityIndex(id))
+
+	if index >= cap(s.sparse) {
+		grown := make([]uint32, index+1, 2*(index+1))
+		copy(grown, s.sparse)
+		s.sparse = grown
+	} else if index >= len(s.sparse) {
+		s.sparse = s.sparse[:index+1] // never written beyond the length, so these are zeroed
+	}
+
+	if s.sparse[index] != 0 {
+		// overwrite, even if the slot belongs to a stale generation of this entity index
+		pos := s.sparse[index] - 1
+		s.entities[pos] = id
+		s.components[pos] = c
+
+		return
+	}
+
+	s.entities = append(s.entities, id)
+	s.components = append(s.components, c)
+	s.sparse[index] = uint32(len(s.entities))
+}
+
+func (s *sparseSetStorage) remove(id EID) bool {
+	pos, found := s.position(id)
+	if !found {
+		return false
+	}
+
+	last := len(s.entities) - 1
+	lastID := s.entities[last]
+
+	s.entities[pos] = lastID
+	s.components[pos] = s.components[last]
+	s.sparse[EntityIndex(lastID)] = uint32(pos + 1)
+
+	s.entities[last] = 0
+	s.components[last] = nil
+	s.entities = s.entities[:last]
+	s.components = s.components[:last]
+
+	s.sparse[EntityIndex(id)] = 0
+	s.index.clear(id)
+
+	return true
+}
+
+func (s *sparseSetStorage) len() int {
+	return len(s.entities)
+}
+
+func (s *sparseSetStorage) each(fn func(EID, Component) bool) {
+	for pos := range s.entities {
+		if !fn(s.entities[pos], s.components[pos]) {
+			return
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package akara
+
+import (
+	"sync/atomic"
+
+	"github.com/gravestench/bitset"
+)
+
+// componentTicks are the change ticks at which a component instance was added, and last changed.
+// Adding a component also counts as changing it.
+type componentTicks struct {
+	added   uint64
+	changed uint64
+}
+
+// ChangeTick returns the current change tick of the world. The change tick advances whenever
+// a system ticks, and components are stamped with the change tick when they are added or
+// changed. See BaseSystem.LastRunTick and Subscription.GetEntitiesSince.
+func (w *World) ChangeTick() uint64 {
+	return atomic.LoadUint64(w.changeTick)
+}
+
+// advanceChangeTick advances the change tick of the world, and yields the new change tick
+func (w *World) advanceChangeTick() uint64 {
+	return atomic.AddUint64(w.changeTick, 1)
+}
+
+// GetMut will yield the component and a bool, like Get, and marks the component as changed.
+// See MarkChanged.
+func (cf *ComponentFactory) GetMut(id EID) (Component, bool) {
+	c, found := cf.Get(id)
+	if found {
+		cf.MarkChanged(id)
+	}
+
+	return c, found
+}
+
+// AddedSince returns true if the component of the entity was added after the given change tick
+func (cf *ComponentFactory) AddedSince(id EID, tick uint64) bool {
+	cf.mux.RLock()
+	defer cf.mux.RUnlock()
+
+	ticks, found := cf.ticks[id]
+
+	return found && ticks.added > tick
+}
+
+// ChangedSince returns true if the component of the entity was added or changed after the
+// given change tick
+func (cf *ComponentFactory) ChangedSince(id EID, tick uint64) bool {
+	cf.mux.RLock()
+	defer cf.mux.RUnlock()
+
+	ticks, found := cf.ticks[id]
+
+	return found && ticks.changed > tick
+}
+
+// changedSince yields the entities whose component was added or changed after the given change tick
+func (cf *ComponentFactory) changedSince(tick uint64) []EID {
+	cf.mux.RLock()
+	defer cf.mux.RUnlock()
+
+	ids := make([]EID, 0)
+
+	for id, ticks := range cf.ticks {
+		if ticks.changed > tick {
+			ids = append(ids, id)
+		}
+	}
+
+	return ids
+}
+
+// GetEntitiesSince returns the entities of the subscription, like GetEntities. If the component
+// filter of the subscription declares Added or Changed components, only the entities whose
+// components were added or changed after the given change tick are returned.
+//
+// Systems usually pass their last run tick, to process only what changed since they last ran:
+//
+//	for _, e := range s.movable.GetEntitiesSince(s.LastRunTick()) {
+//		...
+//	}
+func (s *Subscription) GetEntitiesSince(tick uint64) []EID {
+	entities := s.GetEntities()
+
+	if s.world == nil || !s.Filter.detectsChanges() {
+		return entities
+	}
+
+	added := s.factories(s.Filter.Added)
+	changed := s.factories(s.Filter.Changed)
+
+	result := make([]EID, 0, len(entities))
+
+	for _, id := range entities {
+		if componentsSince(added, id, tick, (*ComponentFactory).AddedSince) &&
+			componentsSince(changed, id, tick, (*ComponentFactory).ChangedSince) {
+			result = append(result, id)
+		}
+	}
+
+	return result
+}
+
+// factories yields the component factories of the bits of the bitset
+func (s *Subscription) factories(bits *bitset.BitSet) []*ComponentFactory {
+	if bits == nil {
+		return nil
+	}
+
+	ids := bits.ToIntArray()
+	factories := make([]*ComponentFactory, 0, len(ids))
+
+	for _, id := range ids {
+		if factory := s.world.GetComponentFactory(ComponentID(id)); factory != nil {
+			factories = append(factories, factory)
+		}
+	}
+
+	return factories
+}
+
+// componentsSince returns true if the check passes for the entity in every factory
+func componentsSince(factories []*ComponentFactory, id EID, tick uint64, check func(*ComponentFactory, EID, uint64) bool) bool {
+	for _, factory := range factories {
+		if !check(factory, id, tick) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// LastRunTick returns the change tick of the last time this system ticked, or 0 if the system
+// has not ticked yet. While the system ticks, this is the change tick of the previous tick.
+func (s *BaseSystem) LastRunTick() uint64 {
+	return s.lastRunTick
+}
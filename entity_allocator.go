@@ -0,0 +1,202 @@
+package akara
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+func newEntityAllocator() *entityAllocator {
+	return &entityAllocator{
+		// index 0 is reserved, so that an EID of 0 never refers to a live entity
+		generations: make([]uint32, 1),
+		alive:       make([]bool, 1),
+		free:        make([]uint32, 0),
+	}
+}
+
+// entityAllocator authors entity ID's. Indices of released entity ID's are kept in a
+// free-list and are recycled with an incremented generation.
+type entityAllocator struct {
+	generations  []uint32 // the current generation for each entity index
+	alive        []bool   // whether the entity index is currently in use
+	free         []uint32 // released entity indices, ready to be recycled
+	placeholders uint64   // the number of placeholder entity ID's yielded, accessed atomically
+	mutex        sync.RWMutex
+}
+
+// allocate yields a new entity ID, recycling a released entity index if one is available
+func (a *entityAllocator) allocate() EID {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	if numFree := len(a.free); numFree > 0 {
+		index := a.free[0]
+		a.free = a.free[1:]
+		a.alive[index] = true
+
+		return NewEntityID(index, a.generations[index])
+	}
+
+	if len(a.generations) > maxEntityIndex {
+		panic("akara: out of entity indices")
+	}
+
+	index := uint32(len(a.generations))
+	a.generations = append(a.generations, 0)
+	a.alive = append(a.alive, true)
+
+	return NewEntityID(index, 0)
+}
+
+// placeholder yields a placeholder entity ID, which is unique within the world, and never
+// refers to an entity. The placeholders count up through the reserved entity indices, and
+// then through their generations.
+func (a *entityAllocator) placeholder() EID {
+	n := atomic.AddUint64(&a.placeholders, 1)
+
+	return NewEntityID(placeholderIndexBit|uint32(n&maxEntityIndex), uint32(n>>(entityIndexBits-1)))
+}
+
+// release marks the entity ID as no longer alive, and places its index in the free-list.
+// Releasing an entity ID which is not alive does nothing, and yields false.
+func (a *entityAllocator) release(id EID) bool {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	if !a.isAlive(id) {
+		return false
+	}
+
+	index := EntityIndex(id)
+	a.alive[index] = false
+	a.generations[index]++
+	a.free = append(a.free, index)
+
+	return true
+}
+
+// contains returns true if the entity ID refers to an entity which has not been released
+func (a *entityAllocator) contains(id EID) bool {
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+
+	return a.isAlive(id)
+}
+
+func (a *entityAllocator) isAlive(id EID) bool {
+	index := EntityIndex(id)
+
+	if int(index) >= len(a.generations) {
+		return false
+	}
+
+	return a.alive[index] && a.generations[index] == EntityGeneration(id)
+}
+
+// entitySnapshot is the state of an entity allocator, as saved in a world snapshot
+type entitySnapshot struct {
+	Generations []uint32
+	Alive       []bool
+	Free        []uint32
+}
+
+// contains returns true if the entity ID was alive when the snapshot was taken
+func (s entitySnapshot) contains(id EID) bool {
+	index := EntityIndex(id)
+
+	if int(index) >= len(s.Generations) || int(index) >= len(s.Alive) {
+		return false
+	}
+
+	return s.Alive[index] && s.Generations[index] == EntityGeneration(id)
+}
+
+// aliveEntities yields the ID of every entity which was alive when the snapshot was taken, in order
+func (s entitySnapshot) aliveEntities() []EID {
+	ids := make([]EID, 0)
+
+	for index := range s.Alive {
+		if s.Alive[index] && index < len(s.Generations) {
+			ids = append(ids, NewEntityID(uint32(index), s.Generations[index]))
+		}
+	}
+
+	return ids
+}
+
+// validate returns an error if the snapshot could not have been taken from an entity allocator
+func (s entitySnapshot) validate() error {
+	if len(s.Alive) != len(s.Generations) {
+		return fmt.Errorf("%d generations for %d entity indices", len(s.Generations), len(s.Alive))
+	}
+
+	if len(s.Alive) > maxEntityIndex+1 {
+		return fmt.Errorf("%d entity indices exceed the maximum of %d", len(s.Alive), maxEntityIndex+1)
+	}
+
+	if len(s.Alive) > 0 && s.Alive[0] {
+		return errors.New("the reserved entity index 0 is alive")
+	}
+
+	free := make(map[uint32]bool, len(s.Free))
+
+	for _, index := range s.Free {
+		switch {
+		case index == 0:
+			return errors.New("the reserved entity index 0 is free")
+		case int(index) >= len(s.Alive):
+			return fmt.Errorf("free entity index %d is out of range", index)
+		case s.Alive[index]:
+			return fmt.Errorf("free entity index %d is alive", index)
+		case free[index]:
+			return fmt.Errorf("free entity index %d is listed twice", index)
+		}
+
+		free[index] = true
+	}
+
+	return nil
+}
+
+func (a *entityAllocator) snapshot() entitySnapshot {
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+
+	return entitySnapshot{
+		Generations: append([]uint32{}, a.generations...),
+		Alive:       append([]bool{}, a.alive...),
+		Free:        append([]uint32{}, a.free...),
+	}
+}
+
+func (a *entityAllocator) restore(s entitySnapshot) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	a.generations = append(make([]uint32, 0, len(s.Generations)), s.Generations...)
+	a.alive = append(make([]bool, 0, len(s.Alive)), s.Alive...)
+	a.free = append(make([]uint32, 0, len(s.Free)), s.Free...)
+
+	// index 0 is always reserved
+	if len(a.generations) == 0 {
+		a.generations, a.alive = make([]uint32, 1), make([]bool, 1)
+	}
+}
+
+// aliveEntities yields the ID of every entity which is alive, in order
+func (a *entityAllocator) aliveEntities() []EID {
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+
+	ids := make([]EID, 0)
+
+	for index := range a.alive {
+		if a.alive[index] {
+			ids = append(ids, NewEntityID(uint32(index), a.generations[index]))
+		}
+	}
+
+	return ids
+}
@@ -1,7 +1,40 @@
 package akara
 
-// EntityID is an entity ID
+// EntityID is an entity ID. The lower 32 bits of the ID are the entity index,
+// and the upper 32 bits are the generation of that index. Whenever an entity is
+// removed, its index is recycled with an incremented generation, so that stale
+// entity ID's can be told apart from live ones.
 type EntityID = uint64
 
 // EID is shorthand for EntityID
 type EID = EntityID
+
+const (
+	entityIndexBits = 32
+	entityIndexMask = 1<<entityIndexBits - 1
+
+	// placeholderIndexBit marks the entity indices which are reserved for the placeholder
+	// entity ID's of command buffers. Entities are never allocated with these indices.
+	placeholderIndexBit = 1 << (entityIndexBits - 1)
+	maxEntityIndex      = placeholderIndexBit - 1
+)
+
+// NewEntityID packs the given entity index and generation into an entity ID
+func NewEntityID(index, generation uint32) EID {
+	return EID(generation)<<entityIndexBits | EID(index)
+}
+
+// EntityIndex returns the index portion of the given entity ID
+func EntityIndex(id EID) uint32 {
+	return uint32(id & entityIndexMask)
+}
+
+// EntityGeneration returns the generation portion of the given entity ID
+func EntityGeneration(id EID) uint32 {
+	return uint32(id >> entityIndexBits)
+}
+
+// isPlaceholder returns true if the entity ID is a placeholder of a command buffer
+func isPlaceholder(id EID) bool {
+	return EntityIndex(id)&placeholderIndexBit != 0
+}
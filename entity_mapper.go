@@ -0,0 +1,161 @@
+package akara
+
+import "sync"
+
+// EntityRemapper is implemented by components which refer to other entities. When a component
+// is brought in from another world, such as by World.Import or a Replica, the entity ID's it
+// refers to are foreign, and RemapEntities is called to translate them to local entity ID's.
+//
+// Example:
+//
+//	func (t *Target) RemapEntities(m *akara.EntityMapper) {
+//		t.Entity = m.Remap(t.Entity)
+//	}
+type EntityRemapper interface {
+	RemapEntities(m *EntityMapper)
+}
+
+// NewEntityMapper creates an entity mapper, which allocates local entities in the given world
+func NewEntityMapper(w *World) *EntityMapper {
+	return &EntityMapper{
+		world:   w,
+		local:   make(map[EID]EID),
+		foreign: make(map[EID]EID),
+	}
+}
+
+// EntityMapper translates the entity ID's of another world, such as a server or a snapshot,
+// to the entity ID's of a local world, and back. Local entities are allocated with
+// World.NewEntity, so foreign entity ID's never collide with local ones.
+//
+// An entity mapper is safe for concurrent use.
+type EntityMapper struct {
+	world   *World
+	local   map[EID]EID // local entity ID's, by foreign entity ID
+	foreign map[EID]EID // foreign entity ID's, by local entity ID
+	mutex   sync.RWMutex
+
+	remapping sync.Mutex   // held while a component is remapped, see remapComponent
+	refs      map[EID]bool // the foreign entity ID's passed to Remap while remapping
+	refsMutex sync.Mutex
+}
+
+// Map yields the local entity ID of the foreign entity ID, creating a new local entity if
+// the foreign entity ID is not mapped yet
+func (m *EntityMapper) Map(foreign EID) EID {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if local, found := m.local[foreign]; found {
+		return local
+	}
+
+	local := m.world.NewEntity()
+	m.local[foreign], m.foreign[local] = local, foreign
+
+	return local
+}
+
+// Set maps the foreign entity ID to the given local entity ID, replacing any existing mapping
+// of either entity ID
+func (m *EntityMapper) Set(foreign, local EID) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if previous, found := m.local[foreign]; found {
+		delete(m.foreign, previous)
+	}
+
+	if previous, found := m.foreign[local]; found {
+		delete(m.local, previous)
+	}
+
+	m.local[foreign], m.foreign[local] = local, foreign
+}
+
+// Local yields the local entity ID of the foreign entity ID, if it is mapped
+func (m *EntityMapper) Local(foreign EID) (EID, bool) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	local, found := m.local[foreign]
+
+	return local, found
+}
+
+// Foreign yields the foreign entity ID of the local entity ID, if it is mapped
+func (m *EntityMapper) Foreign(local EID) (EID, bool) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	foreign, found := m.foreign[local]
+
+	return foreign, found
+}
+
+// Remap yields the local entity ID of the foreign entity ID. Foreign entity ID's which are not
+// mapped yield 0, which never refers to a live entity. This is meant to be used by EntityRemapper
+// components, so that references to entities which were not brought along do not refer to
+// unrelated local entities. A Replica remaps the component again once such an entity arrives.
+func (m *EntityMapper) Remap(foreign EID) EID {
+	m.refsMutex.Lock()
+	if m.refs != nil {
+		m.refs[foreign] = true
+	}
+	m.refsMutex.Unlock()
+
+	local, _ := m.Local(foreign)
+
+	return local
+}
+
+// Forget removes the mapping of the foreign entity ID, and yields the local entity ID it was
+// mapped to. The local entity itself is not removed.
+func (m *EntityMapper) Forget(foreign EID) (EID, bool) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	local, found := m.local[foreign]
+	if found {
+		delete(m.local, foreign)
+		delete(m.foreign, local)
+	}
+
+	return local, found
+}
+
+// Len returns the number of mapped entities
+func (m *EntityMapper) Len() int {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	return len(m.local)
+}
+
+// remapComponent translates the entity references of the component, if it has any, and yields
+// the foreign entity ID's that the component referred to, mapped or not
+func (m *EntityMapper) remapComponent(c Component) []EID {
+	remapper, ok := c.(EntityRemapper)
+	if !ok {
+		return nil
+	}
+
+	m.remapping.Lock()
+	defer m.remapping.Unlock()
+
+	m.refsMutex.Lock()
+	m.refs = make(map[EID]bool)
+	m.refsMutex.Unlock()
+
+	remapper.RemapEntities(m)
+
+	m.refsMutex.Lock()
+	refs := make([]EID, 0, len(m.refs))
+	for foreign := range m.refs {
+		refs = append(refs, foreign)
+	}
+	m.refs = nil
+	m.refsMutex.Unlock()
+
+	return refs
+}
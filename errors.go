@@ -0,0 +1,41 @@
+package akara
+
+import "errors"
+
+var (
+	// ErrComponentNameConflict is returned when a component name is claimed by more than one component type
+	ErrComponentNameConflict = errors.New("component name conflict")
+
+	// ErrSystemOrder is returned when the ordering constraints of the systems cannot be satisfied
+	ErrSystemOrder = errors.New("invalid system order")
+
+	// ErrWorldHalted is returned by World.Update once the world has been halted. See HaltOnError.
+	ErrWorldHalted = errors.New("world halted")
+
+	// ErrWorldShutdown is returned by World.Update once the world has been shut down. See World.Shutdown.
+	ErrWorldShutdown = errors.New("world shut down")
+
+	// ErrInvalidCommand is returned when a command buffer has a command which cannot be applied. See CommandBuffer.Apply.
+	ErrInvalidCommand = errors.New("invalid command")
+
+	// ErrSnapshotVersion is returned when restoring a snapshot with an unsupported version
+	ErrSnapshotVersion = errors.New("unsupported snapshot version")
+
+	// ErrInvalidSnapshot is returned when restoring a snapshot whose entities are inconsistent
+	ErrInvalidSnapshot = errors.New("invalid snapshot")
+
+	// ErrUnknownComponent is returned when restoring a snapshot with a component name which is not registered
+	ErrUnknownComponent = errors.New("unknown component")
+
+	// ErrUnsupportedComponent is returned when a component contains values which cannot be encoded by the ReflectCodec
+	ErrUnsupportedComponent = errors.New("unsupported component")
+
+	// ErrRollbackUnavailable is returned when a world cannot be rewound to a frame. See World.Rewind.
+	ErrRollbackUnavailable = errors.New("rollback unavailable")
+
+	// ErrUntrackedChange is returned when rewinding a world with a component which was changed without MarkChanged
+	ErrUntrackedChange = errors.New("component changed without MarkChanged")
+
+	// ErrReplicationOutOfSync is returned by a Replica when a packet does not follow the previous packet
+	ErrReplicationOutOfSync = errors.New("replication out of sync")
+)
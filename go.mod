@@ -1,8 +1,13 @@
 module github.com/gravestench/akara
 
-go 1.14
+go 1.18
 
 require (
 	github.com/gravestench/bitset v0.0.0-20210906032249-537b6b7a3398
 	github.com/smartystreets/goconvey v1.6.4
 )
+
+require (
+	github.com/jtolds/gls v4.20.0+incompatible // indirect
+	github.com/smartystreets/assertions v0.0.0-20180927180507-b2de0cb4f26d // indirect
+)
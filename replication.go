@@ -0,0 +1,780 @@
+package akara
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+)
+
+// maxReplicationPacketSize is the size of the largest packet that a Replica will accept
+const maxReplicationPacketSize = 1 << 26
+
+// replicationUpdateKind is the kind of change to a component instance in a replication packet
+type replicationUpdateKind byte
+
+const (
+	// the component instance is sent in full
+	replicateFull replicationUpdateKind = iota
+	// the component instance is sent as a patch of the previous instance
+	replicatePatch
+	// the component instance was removed
+	replicateRemoved
+)
+
+// SetNetworked sets whether the component instances of this component type are replicated
+// to remote peers. See Replicator.
+func (cf *ComponentFactory) SetNetworked(networked bool) {
+	cf.mux.Lock()
+	defer cf.mux.Unlock()
+
+	cf.networked = networked
+}
+
+// Networked returns true if the component instances of this component type are replicated
+// to remote peers
+func (cf *ComponentFactory) Networked() bool {
+	cf.mux.RLock()
+	defer cf.mux.RUnlock()
+
+	return cf.networked
+}
+
+// NewReplicator creates a replicator for the networked components of the given world
+func NewReplicator(w *World) *Replicator {
+	return &Replicator{
+		world:   w,
+		clients: make([]*ReplicationClient, 0),
+	}
+}
+
+// Replicator sends the networked components of an authoritative world to remote peers.
+// See ComponentFactory.SetNetworked.
+//
+// For every client, the replicator keeps the state that the client was last sent, and only
+// sends what changed since: the entities which were spawned or despawned, and the component
+// instances which were added, changed, or removed. Changed component instances are sent as a
+// patch of the previous instance, when that is smaller. The packets must therefore be delivered
+// in order and without loss, like over a TCP connection. On the remote side, a Replica applies
+// the packets to its own world.
+//
+// Components are identified by their registered names, so the names must be the same in both
+// worlds; see RegisterNamedComponent. Networked component types which do not claim their name
+// cannot be replicated. The component instances are encoded with the codec of
+// their component type; see RegisterComponentCodec.
+type Replicator struct {
+	world   *World
+	clients []*ReplicationClient
+	mutex   sync.Mutex
+}
+
+// ReplicationClient is a remote peer of a Replicator
+type ReplicationClient struct {
+	dst      io.Writer
+	sequence uint64
+	declared map[ComponentID]bool
+	baseline rollbackState
+	entities map[EID]bool
+}
+
+// AddClient adds a remote peer, which is sent packets by writing to the given writer.
+// The first packet sent to the client spawns every replicated entity.
+func (r *Replicator) AddClient(dst io.Writer) *ReplicationClient {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	c := &ReplicationClient{
+		dst:      dst,
+		declared: make(map[ComponentID]bool),
+		baseline: make(rollbackState),
+		entities: make(map[EID]bool),
+	}
+
+	r.clients = append(r.clients, c)
+
+	return c
+}
+
+// RemoveClient removes a remote peer. Returns false if the client was not added.
+func (r *Replicator) RemoveClient(c *ReplicationClient) bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for idx := range r.clients {
+		if r.clients[idx] == c {
+			r.clients = append(r.clients[:idx], r.clients[idx+1:]...)
+			return true
+		}
+	}
+
+	return false
+}
+
+// Replicate sends a packet to every client, with the changes since the client was last sent
+// a packet. Clients for which nothing changed are not sent a packet. Entities are replicated
+// while they have at least one networked component.
+//
+// If a packet cannot be written, the other clients are still sent their packets, and the first
+// error is returned. The failed client should be removed, as it is no longer in sync.
+// Like Snapshot, Replicate should not be called while systems are ticking.
+func (r *Replicator) Replicate() error {
+	factories := make([]*ComponentFactory, 0)
+
+	for _, factory := range r.world.sortedFactories() {
+		if !factory.Networked() {
+			continue
+		}
+
+		if !r.world.claimsName(factory) {
+			const errFmt = "could not replicate: %w: %v does not claim %q"
+			return fmt.Errorf(errFmt, ErrComponentNameConflict, factory.typ, factory.Name())
+		}
+
+		factories = append(factories, factory)
+	}
+
+	state, err := r.world.encodeFactories(factories)
+	if err != nil {
+		return fmt.Errorf("could not replicate: %w", err)
+	}
+
+	names := make(map[ComponentID]string, len(factories))
+	for _, factory := range factories {
+		names[factory.id] = factory.Name()
+	}
+
+	entities := make(map[EID]bool)
+
+	for _, instances := range state {
+		for id := range instances {
+			entities[id] = true
+		}
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	var firstErr error
+
+	for _, c := range r.clients {
+		if err := c.send(state, entities, names); err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("could not replicate: %w", err)
+		}
+	}
+
+	return firstErr
+}
+
+// send writes a packet with the changes between the baseline of the client and the given state
+func (c *ReplicationClient) send(state rollbackState, entities map[EID]bool, names map[ComponentID]string) error {
+	spawns, despawns := diffEntities(c.entities, entities), diffEntities(entities, c.entities)
+
+	updates := &bytes.Buffer{}
+	numUpdates := 0
+	declarations := make([]ComponentID, 0)
+
+	for _, component := range sortedComponentIDs(state, c.baseline) {
+		for _, id := range sortedEntityIDs(state[component], c.baseline[component]) {
+			if !entities[id] {
+				continue // the entity is despawned, along with its components
+			}
+
+			data, found := state.get(component, id)
+			previous, sent := c.baseline.get(component, id)
+
+			if found && sent && bytes.Equal(data, previous) {
+				continue
+			}
+
+			putUvarint(updates, uint64(id))
+			putUvarint(updates, uint64(component))
+			numUpdates++
+
+			if !c.declared[component] {
+				c.declared[component] = true
+				declarations = append(declarations, component)
+			}
+
+			switch patch, ok := diffBytes(previous, data); {
+			case !found:
+				updates.WriteByte(byte(replicateRemoved))
+			case sent && ok:
+				updates.WriteByte(byte(replicatePatch))
+				putBytes(updates, patch)
+			default:
+				updates.WriteByte(byte(replicateFull))
+				putBytes(updates, data)
+			}
+		}
+	}
+
+	if len(spawns)+len(despawns)+numUpdates == 0 {
+		return nil
+	}
+
+	payload := &bytes.Buffer{}
+	putUvarint(payload, c.sequence+1)
+
+	putUvarint(payload, uint64(len(declarations)))
+	for _, component := range declarations {
+		putUvarint(payload, uint64(component))
+		putBytes(payload, []byte(names[component]))
+	}
+
+	for _, ids := range [][]EID{spawns, despawns} {
+		putUvarint(payload, uint64(len(ids)))
+		for _, id := range ids {
+			putUvarint(payload, uint64(id))
+		}
+	}
+
+	putUvarint(payload, uint64(numUpdates))
+	payload.Write(updates.Bytes())
+
+	packet := &bytes.Buffer{}
+	putBytes(packet, payload.Bytes())
+
+	if _, err := c.dst.Write(packet.Bytes()); err != nil {
+		for _, component := range declarations {
+			delete(c.declared, component)
+		}
+
+		return err
+	}
+
+	c.sequence++
+	c.baseline, c.entities = state, entities
+
+	return nil
+}
+
+// NewReplica creates a replica, which applies the packets of a Replicator, read from the
+// given reader, to the given world
+func NewReplica(w *World, src io.Reader) *Replica {
+	r := &Replica{
+		world:      w,
+		components: make(map[ComponentID]*ComponentFactory),
+		names:      make(map[ComponentID]string),
+		mapper:     NewEntityMapper(w),
+		baseline:   make(rollbackState),
+		references: make(map[replicaInstance][]EID),
+		referrers:  make(map[EID]map[replicaInstance]bool),
+	}
+
+	if br, ok := src.(byteReader); ok {
+		r.src = br
+	} else {
+		r.src = bufio.NewReader(src)
+	}
+
+	return r
+}
+
+type byteReader interface {
+	io.Reader
+	io.ByteReader
+}
+
+// Replica applies the packets of a Replicator to a world. Replicated entities are created in
+// the world of the replica, so their entity ID's differ from the entity ID's of the replicator;
+// see Mapper. Replicated components are decoded into new component instances, which replace
+// the existing instances, and the subscriptions and component observers are notified as usual.
+// Components which implement EntityRemapper are remapped before they are set, and they are
+// remapped again whenever an entity they refer to is spawned or despawned, so a reference to
+// an entity that arrives in a later packet is resolved once it arrives, and a reference to
+// a despawned entity becomes 0.
+type Replica struct {
+	world      *World
+	src        byteReader
+	sequence   uint64
+	components map[ComponentID]*ComponentFactory // by the component ID of the replicator
+	names      map[ComponentID]string
+	mapper     *EntityMapper                    // maps the entity ID's of the replicator to local entity ID's
+	baseline   rollbackState                    // by the component ID and entity ID of the replicator
+	references map[replicaInstance][]EID        // the entities that each instance refers to
+	referrers  map[EID]map[replicaInstance]bool // the instances that refer to each entity
+	mutex      sync.RWMutex
+}
+
+// replicaInstance identifies a component instance by the component ID and entity ID of the replicator
+type replicaInstance struct {
+	component ComponentID
+	entity    EID
+}
+
+// replicationPacket is a decoded packet of a Replicator
+type replicationPacket struct {
+	sequence     uint64
+	declarations map[ComponentID]string
+	spawns       []EID
+	despawns     []EID
+	updates      []replicationUpdate
+}
+
+type replicationUpdate struct {
+	entity    EID
+	component ComponentID
+	kind      replicationUpdateKind
+	data      []byte
+}
+
+// LocalEntity yields the local entity ID of an entity of the replicator
+func (r *Replica) LocalEntity(remote EID) (EID, bool) {
+	return r.mapper.Local(remote)
+}
+
+// Mapper returns the entity mapper of the replica, which maps the entity ID's of the replicator
+// to local entity ID's
+func (r *Replica) Mapper() *EntityMapper {
+	return r.mapper
+}
+
+// Receive reads the next packet, blocking until it is available, and applies it to the world.
+// Components which are not registered in the world are skipped, and an error wrapping
+// ErrUnknownComponent is returned once the rest of the packet has been applied. Other errors
+// mean that the replica is no longer in sync with the replicator.
+//
+// Like Restore, Receive should not be called while systems are ticking.
+func (r *Replica) Receive() error {
+	size, err := binary.ReadUvarint(r.src)
+	if err != nil {
+		return err
+	}
+
+	if size > maxReplicationPacketSize {
+		return fmt.Errorf("%w: packet of %d bytes is too large", ErrReplicationOutOfSync, size)
+	}
+
+	payload := make([]byte, size)
+	if _, err := io.ReadFull(r.src, payload); err != nil {
+		return fmt.Errorf("could not read packet: %w", err)
+	}
+
+	p, err := decodePacket(payload)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrReplicationOutOfSync, err)
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if p.sequence != r.sequence+1 {
+		return fmt.Errorf("%w: packet %d follows packet %d", ErrReplicationOutOfSync, p.sequence, r.sequence)
+	}
+
+	r.sequence = p.sequence
+
+	return r.apply(p)
+}
+
+// apply applies a decoded packet to the world
+func (r *Replica) apply(p *replicationPacket) error {
+	for component, name := range p.declarations {
+		r.names[component] = name
+
+		if id, found := r.world.GetComponentID(name); found {
+			r.components[component] = r.world.GetComponentFactory(id)
+		}
+	}
+
+	for _, remote := range p.spawns {
+		if _, found := r.mapper.Local(remote); found {
+			return fmt.Errorf("%w: entity %d was spawned twice", ErrReplicationOutOfSync, remote)
+		}
+
+		r.mapper.Map(remote)
+	}
+
+	unknown := make([]string, 0)
+
+	for _, u := range p.updates {
+		local, found := r.mapper.Local(u.entity)
+		if !found {
+			return fmt.Errorf("%w: entity %d was not spawned", ErrReplicationOutOfSync, u.entity)
+		}
+
+		instance := replicaInstance{u.component, u.entity}
+
+		if u.kind == replicateRemoved {
+			delete(r.baseline[u.component], u.entity)
+			r.untrack(instance)
+
+			if factory := r.components[u.component]; factory != nil {
+				factory.Remove(local)
+			}
+
+			continue
+		}
+
+		data := u.data
+
+		if u.kind == replicatePatch {
+			previous, found := r.baseline.get(u.component, u.entity)
+			if !found {
+				return fmt.Errorf("%w: no %s of entity %d to patch", ErrReplicationOutOfSync, r.names[u.component], u.entity)
+			}
+
+			var err error
+			if data, err = applyPatch(previous, u.data); err != nil {
+				return fmt.Errorf("%w: %v", ErrReplicationOutOfSync, err)
+			}
+		}
+
+		r.baseline.put(u.component, u.entity, data)
+
+		factory := r.components[u.component]
+		if factory == nil {
+			unknown = append(unknown, r.names[u.component])
+			continue
+		}
+
+		if err := r.applyComponent(factory, instance, local, data); err != nil {
+			return err
+		}
+	}
+
+	if err := r.resolveReferences(p.spawns); err != nil {
+		return err
+	}
+
+	despawned := make([]EID, 0, len(p.despawns))
+
+	for _, remote := range p.despawns {
+		local, found := r.mapper.Forget(remote)
+		if !found {
+			continue
+		}
+
+		for component, instances := range r.baseline {
+			delete(instances, remote)
+			r.untrack(replicaInstance{component, remote})
+		}
+
+		r.world.RemoveEntity(local)
+		despawned = append(despawned, remote)
+	}
+
+	if err := r.resolveReferences(despawned); err != nil {
+		return err
+	}
+
+	if len(unknown) > 0 {
+		return fmt.Errorf("%w: %q", ErrUnknownComponent, unknown)
+	}
+
+	return nil
+}
+
+// applyComponent decodes the component instance, remaps its entity references, and then sets
+// it as the component of the local entity, so that the observers only see decoded components
+func (r *Replica) applyComponent(factory *ComponentFactory, instance replicaInstance, local EID, data []byte) error {
+	c, err := factory.decode(data)
+	if err != nil {
+		return fmt.Errorf("could not decode %s of entity %d: %w", factory.Name(), local, err)
+	}
+
+	r.track(instance, r.mapper.remapComponent(c))
+	factory.set(local, c) // does nothing if the entity has been removed locally
+
+	return nil
+}
+
+// resolveReferences applies the instances which refer to the given entities again, from the
+// baseline, so that their references follow the entities as they are spawned or despawned
+func (r *Replica) resolveReferences(remotes []EID) error {
+	instances := make([]replicaInstance, 0)
+	seen := make(map[replicaInstance]bool)
+
+	for _, remote := range remotes {
+		for instance := range r.referrers[remote] {
+			if !seen[instance] {
+				seen[instance] = true
+				instances = append(instances, instance)
+			}
+		}
+	}
+
+	sort.Slice(instances, func(i, j int) bool {
+		if instances[i].component != instances[j].component {
+			return instances[i].component < instances[j].component
+		}
+
+		return instances[i].entity < instances[j].entity
+	})
+
+	for _, instance := range instances {
+		local, found := r.mapper.Local(instance.entity)
+		if !found {
+			continue
+		}
+
+		data, found := r.baseline.get(instance.component, instance.entity)
+		factory := r.components[instance.component]
+
+		if !found || factory == nil {
+			continue
+		}
+
+		if err := r.applyComponent(factory, instance, local, data); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// track records the entities that the instance refers to, replacing what it referred to before
+func (r *Replica) track(instance replicaInstance, refs []EID) {
+	r.untrack(instance)
+
+	if len(refs) == 0 {
+		return
+	}
+
+	r.references[instance] = refs
+
+	for _, remote := range refs {
+		if r.referrers[remote] == nil {
+			r.referrers[remote] = make(map[replicaInstance]bool)
+		}
+
+		r.referrers[remote][instance] = true
+	}
+}
+
+// untrack forgets the entities that the instance refers to
+func (r *Replica) untrack(instance replicaInstance) {
+	for _, remote := range r.references[instance] {
+		delete(r.referrers[remote], instance)
+
+		if len(r.referrers[remote]) == 0 {
+			delete(r.referrers, remote)
+		}
+	}
+
+	delete(r.references, instance)
+}
+
+// decodePacket decodes the payload of a packet, without applying it
+func decodePacket(payload []byte) (*replicationPacket, error) {
+	r := bytes.NewReader(payload)
+	p := &replicationPacket{declarations: make(map[ComponentID]string)}
+
+	var err error
+
+	readUvarint := func() uint64 {
+		var v uint64
+		if err == nil {
+			v, err = binary.ReadUvarint(r)
+		}
+
+		return v
+	}
+
+	readBytes := func() []byte {
+		var data []byte
+		if err == nil {
+			data, err = decodeBytes(r)
+		}
+
+		return data
+	}
+
+	p.sequence = readUvarint()
+
+	for n := readUvarint(); err == nil && n > 0; n-- {
+		component := ComponentID(readUvarint())
+		p.declarations[component] = string(readBytes())
+	}
+
+	for _, ids := range []*[]EID{&p.spawns, &p.despawns} {
+		for n := readUvarint(); err == nil && n > 0; n-- {
+			*ids = append(*ids, EID(readUvarint()))
+		}
+	}
+
+	for n := readUvarint(); err == nil && n > 0; n-- {
+		u := replicationUpdate{
+			entity:    EID(readUvarint()),
+			component: ComponentID(readUvarint()),
+		}
+
+		var kind byte
+		if err == nil {
+			kind, err = r.ReadByte()
+		}
+
+		u.kind = replicationUpdateKind(kind)
+
+		switch u.kind {
+		case replicateFull, replicatePatch:
+			u.data = readBytes()
+		case replicateRemoved:
+		default:
+			if err == nil {
+				err = fmt.Errorf("unknown update kind %d", kind)
+			}
+		}
+
+		p.updates = append(p.updates, u)
+	}
+
+	if err == nil && r.Len() > 0 {
+		err = fmt.Errorf("%d bytes left over", r.Len())
+	}
+
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+
+	return p, err
+}
+
+// diffEntities yields the entities which are in b, but not in a, in order
+func diffEntities(a, b map[EID]bool) []EID {
+	ids := make([]EID, 0)
+
+	for id := range b {
+		if !a[id] {
+			ids = append(ids, id)
+		}
+	}
+
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	return ids
+}
+
+// sortedComponentIDs yields the component ID's of both states, in order
+func sortedComponentIDs(a, b rollbackState) []ComponentID {
+	seen := make(map[ComponentID]bool)
+	ids := make([]ComponentID, 0)
+
+	for _, s := range []rollbackState{a, b} {
+		for id := range s {
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+	}
+
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	return ids
+}
+
+// sortedEntityIDs yields the entity ID's of both instance maps, in order
+func sortedEntityIDs(a, b map[EID][]byte) []EID {
+	seen := make(map[EID]bool)
+	ids := make([]EID, 0)
+
+	for _, instances := range []map[EID][]byte{a, b} {
+		for id := range instances {
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+	}
+
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	return ids
+}
+
+// diffBytes yields a patch which turns previous into next, made of the runs of bytes which
+// differ. Returns false if the lengths differ, or if the patch would not be smaller than next.
+func diffBytes(previous, next []byte) ([]byte, bool) {
+	if len(previous) != len(next) {
+		return nil, false
+	}
+
+	type run struct{ start, end int }
+
+	runs := make([]run, 0)
+
+	for idx := 0; idx < len(next); {
+		if previous[idx] == next[idx] {
+			idx++
+			continue
+		}
+
+		start := idx
+		for idx < len(next) && previous[idx] != next[idx] {
+			idx++
+		}
+
+		runs = append(runs, run{start, idx})
+	}
+
+	patch := &bytes.Buffer{}
+	putUvarint(patch, uint64(len(runs)))
+
+	end := 0
+
+	for _, r := range runs {
+		putUvarint(patch, uint64(r.start-end))
+		putBytes(patch, next[r.start:r.end])
+		end = r.end
+	}
+
+	if patch.Len() >= len(next) {
+		return nil, false
+	}
+
+	return patch.Bytes(), true
+}
+
+// applyPatch applies a patch made by diffBytes
+func applyPatch(previous, patch []byte) ([]byte, error) {
+	next := append([]byte{}, previous...)
+	r := bytes.NewReader(patch)
+
+	numRuns, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, err
+	}
+
+	pos := uint64(0)
+
+	for ; numRuns > 0; numRuns-- {
+		skip, err := binary.ReadUvarint(r)
+		if err != nil {
+			return nil, err
+		}
+
+		data, err := decodeBytes(r)
+		if err != nil {
+			return nil, err
+		}
+
+		// the patch comes from the network, so neither the skip nor the run may be trusted
+		remaining := uint64(len(next)) - pos
+		if skip > remaining || uint64(len(data)) > remaining-skip {
+			return nil, fmt.Errorf("patch exceeds %d bytes", len(next))
+		}
+
+		pos += skip
+		pos += uint64(copy(next[pos:], data))
+	}
+
+	if r.Len() > 0 {
+		return nil, fmt.Errorf("patch has %d bytes left over", r.Len())
+	}
+
+	return next, nil
+}
+
+func putUvarint(buf *bytes.Buffer, v uint64) {
+	var scratch [binary.MaxVarintLen64]byte
+	buf.Write(scratch[:binary.PutUvarint(scratch[:], v)])
+}
+
+func putBytes(buf *bytes.Buffer, data []byte) {
+	putUvarint(buf, uint64(len(data)))
+	buf.Write(data)
+}
@@ -0,0 +1,526 @@
+package akara
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gravestench/bitset"
+)
+
+// rollbackState holds encoded component instances, by component ID and entity ID.
+// A nil instance means that the entity does not have the component.
+type rollbackState map[ComponentID]map[EID][]byte
+
+func (s rollbackState) get(component ComponentID, id EID) (data []byte, found bool) {
+	data, found = s[component][id]
+	return data, found
+}
+
+func (s rollbackState) put(component ComponentID, id EID, data []byte) {
+	if s[component] == nil {
+		s[component] = make(map[EID][]byte)
+	}
+
+	s[component][id] = data
+}
+
+// rollbackFrame is the recorded state of the world after a frame. Only the component
+// instances which changed during the frame are recorded, as they were before the frame.
+type rollbackFrame struct {
+	frame        uint64
+	entities     entitySnapshot
+	elapsed      map[System]time.Duration
+	accumulators map[System]time.Duration
+	undo         rollbackState
+}
+
+func newRollback(capacity int) *rollback {
+	return &rollback{
+		frames: make([]*rollbackFrame, capacity),
+		state:  make(rollbackState),
+	}
+}
+
+// rollback is a ring buffer of recorded frames. The component instances of the latest frame
+// are kept in full, and earlier frames are reconstructed by undoing the frames after them.
+type rollback struct {
+	mutex  sync.Mutex
+	frames []*rollbackFrame
+	start  int // the index of the oldest frame
+	count  int
+	state  rollbackState // the component instances of the latest frame
+	tick   uint64        // the change tick of the latest frame; later changes are recorded with the next frame
+}
+
+// at yields the recorded frame at the given position, where 0 is the oldest frame
+func (r *rollback) at(pos int) *rollbackFrame {
+	return r.frames[(r.start+pos)%len(r.frames)]
+}
+
+// push records a frame, overwriting the oldest frame if the ring buffer is full
+func (r *rollback) push(f *rollbackFrame) {
+	if r.count == len(r.frames) {
+		r.frames[r.start] = nil
+		r.start = (r.start + 1) % len(r.frames)
+		r.count--
+	}
+
+	r.frames[(r.start+r.count)%len(r.frames)] = f
+	r.count++
+}
+
+// find yields the position of the recorded frame with the given frame number. The frames
+// are recorded in order, but frames which could not be recorded are missing.
+func (r *rollback) find(frame uint64) (int, bool) {
+	pos := sort.Search(r.count, func(pos int) bool {
+		return r.at(pos).frame >= frame
+	})
+
+	return pos, pos < r.count && r.at(pos).frame == frame
+}
+
+// Frame returns the number of world updates which have completed. With rollback enabled,
+// this is the frame that World.Rewind would return to, if the world had not changed since.
+// See WorldConfig.WithRollback.
+func (w *World) Frame() uint64 {
+	return atomic.LoadUint64(&w.frame)
+}
+
+// RollbackFrames returns the oldest and the latest frame that the world can be rewound to.
+// If rollback is not enabled, or no frame has been recorded yet, ok is false.
+func (w *World) RollbackFrames() (oldest, latest uint64, ok bool) {
+	if w.rollback == nil {
+		return 0, 0, false
+	}
+
+	w.rollback.mutex.Lock()
+	defer w.rollback.mutex.Unlock()
+
+	if w.rollback.count == 0 {
+		return 0, 0, false
+	}
+
+	return w.rollback.at(0).frame, w.rollback.at(w.rollback.count - 1).frame, true
+}
+
+// Rewind restores the entities and components of the world to the state they were in after
+// the given frame, along with the time that the systems have accumulated towards their next
+// tick. Only the component instances which differ are replaced, and the subscriptions and
+// component observers are notified as usual. Frames after the given frame are forgotten,
+// and the next world update simulates the frame after it again.
+//
+// Pending commands and entity removals are not discarded, and are applied during the next
+// world update. This is how corrected inputs can be given to a resimulated frame.
+//
+// Only the component instances which were added or changed are recorded with each frame, so
+// components must be changed through GetMut or MarkChanged. A component which was changed in
+// place after Get cannot be rewound, because it is unknown when it changed. If such a component
+// is found, an error wrapping ErrUntrackedChange is returned, and the world is left untouched.
+//
+// Like Snapshot, Rewind should not be called while systems are ticking. An error wrapping
+// ErrRollbackUnavailable is returned if rollback is not enabled, or the frame is no longer
+// (or not yet, or never) recorded. See WorldConfig.WithRollback.
+func (w *World) Rewind(frame uint64) error {
+	r := w.rollback
+	if r == nil {
+		return fmt.Errorf("%w: rollback is not enabled", ErrRollbackUnavailable)
+	}
+
+	if !w.lifecycle.begin() {
+		return ErrWorldShutdown
+	}
+
+	defer w.lifecycle.end()
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	pos, found := r.find(frame)
+	if !found {
+		return fmt.Errorf("%w: frame %d is not recorded", ErrRollbackUnavailable, frame)
+	}
+
+	live, err := w.encodeComponents()
+	if err != nil {
+		return fmt.Errorf("could not rewind to frame %d: %w", frame, err)
+	}
+
+	if err := w.checkTrackedChanges(r.state, live, r.tick); err != nil {
+		return fmt.Errorf("could not rewind to frame %d: %w", frame, err)
+	}
+
+	// undo the later frames, latest first, so that the earliest instance of each component wins
+	undo := make(rollbackState)
+
+	for idx := r.count - 1; idx > pos; idx-- {
+		for component, instances := range r.at(idx).undo {
+			for id, data := range instances {
+				undo.put(component, id, data)
+			}
+		}
+	}
+
+	target := r.at(pos)
+	w.rewindEntities(target.entities, live)
+
+	if err := w.rewindComponents(r.state, undo, live); err != nil {
+		return fmt.Errorf("could not rewind to frame %d: %w", frame, err)
+	}
+
+	for component, instances := range undo {
+		for id, data := range instances {
+			if data == nil {
+				delete(r.state[component], id)
+				continue
+			}
+
+			r.state.put(component, id, data)
+		}
+	}
+
+	for idx := pos + 1; idx < r.count; idx++ {
+		r.frames[(r.start+idx)%len(r.frames)] = nil
+	}
+
+	r.count = pos + 1
+
+	// the components which were replaced are recorded in the state already
+	r.tick = w.advanceChangeTick() - 1
+
+	w.restoreScheduler(target)
+	atomic.StoreUint64(&w.frame, frame)
+
+	return nil
+}
+
+// Resimulate rewinds the world to the given frame, and then updates the world with the given
+// time delta until it is back at the current frame. Before each frame is simulated, the input
+// function is called with the number of the frame, so that corrected inputs can be applied.
+//
+// With a fixed time delta and deterministic systems, the world ends up in the same state as
+// it would have if the corrected inputs had been known all along.
+func (w *World) Resimulate(frame uint64, timeDelta time.Duration, input func(frame uint64)) error {
+	current := w.Frame()
+
+	if err := w.Rewind(frame); err != nil {
+		return err
+	}
+
+	for f := frame; f < current; f++ {
+		if input != nil {
+			input(f)
+		}
+
+		if err := w.Update(timeDelta); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// beginFrame records the initial frame, if rollback is enabled and no frame is recorded yet
+func (w *World) beginFrame() error {
+	if w.rollback == nil {
+		return nil
+	}
+
+	w.rollback.mutex.Lock()
+	empty := w.rollback.count == 0
+	w.rollback.mutex.Unlock()
+
+	if !empty {
+		return nil
+	}
+
+	return w.recordFrame(w.Frame())
+}
+
+// endFrame advances the frame, records it if rollback is enabled, and yields the error of
+// the world update
+func (w *World) endFrame() error {
+	frameErr := w.recordFrame(atomic.AddUint64(&w.frame, 1))
+
+	if err := w.updateError(); err != nil {
+		return err
+	}
+
+	return frameErr
+}
+
+// recordFrame records the state of the world as the given frame. Only the component instances
+// which were added or changed since the latest frame are encoded; see ComponentFactory.ChangedSince.
+// If a component instance cannot be encoded, the frame is not recorded, and the changes are
+// recorded with the next frame instead.
+func (w *World) recordFrame(frame uint64) error {
+	r := w.rollback
+	if r == nil {
+		return nil
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	changed, err := w.encodeChanges(r.state, r.tick)
+	if err != nil {
+		return fmt.Errorf("could not record frame %d: %w", frame, err)
+	}
+
+	f := &rollbackFrame{
+		frame:    frame,
+		entities: w.entities.snapshot(),
+		undo:     make(rollbackState),
+	}
+
+	f.elapsed, f.accumulators = w.schedulerState()
+
+	for component, instances := range changed {
+		for id, data := range instances {
+			previous, found := r.state.get(component, id)
+			if found && bytes.Equal(previous, data) {
+				continue
+			}
+
+			f.undo.put(component, id, previous)
+
+			if data == nil {
+				delete(r.state[component], id)
+				continue
+			}
+
+			r.state.put(component, id, data)
+		}
+	}
+
+	// changes made from now on are stamped with a later change tick
+	r.tick = w.advanceChangeTick() - 1
+	r.push(f)
+
+	return nil
+}
+
+// encodeChanges encodes the component instances which were added or changed after the given
+// change tick. Component instances of the latest state which no longer exist yield nil.
+func (w *World) encodeChanges(latest rollbackState, tick uint64) (rollbackState, error) {
+	changes := make(rollbackState)
+
+	for _, factory := range w.sortedFactories() {
+		codec, _ := factory.codec()
+
+		for _, id := range factory.changedSince(tick) {
+			c, found := factory.Get(id)
+			if !found {
+				continue
+			}
+
+			data, err := codec.Marshal(c)
+			if err != nil {
+				return nil, fmt.Errorf("could not encode %s of entity %d: %w", factory.Name(), id, err)
+			}
+
+			if data == nil {
+				data = []byte{} // nil means that there is no component instance
+			}
+
+			changes.put(factory.id, id, data)
+		}
+
+		for id := range latest[factory.id] {
+			if _, found := factory.Get(id); !found {
+				changes.put(factory.id, id, nil)
+			}
+		}
+	}
+
+	return changes, nil
+}
+
+// encodeComponents encodes every component instance of the world
+func (w *World) encodeComponents() (rollbackState, error) {
+	return w.encodeFactories(w.sortedFactories())
+}
+
+// encodeFactories encodes every component instance of the given factories
+func (w *World) encodeFactories(factories []*ComponentFactory) (rollbackState, error) {
+	state := make(rollbackState)
+
+	for _, factory := range factories {
+		codec, _ := factory.codec()
+
+		var err error
+
+		factory.Each(func(id EID, c Component) bool {
+			var data []byte
+
+			if data, err = codec.Marshal(c); err != nil {
+				err = fmt.Errorf("could not encode %s of entity %d: %w", factory.Name(), id, err)
+				return false
+			}
+
+			if data == nil {
+				data = []byte{} // nil means that there is no component instance
+			}
+
+			state.put(factory.id, id, data)
+
+			return true
+		})
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return state, nil
+}
+
+// checkTrackedChanges yields an error for the first live component instance which differs from
+// the latest recorded state, although it was not marked as changed since that state was recorded
+func (w *World) checkTrackedChanges(latest, live rollbackState, tick uint64) error {
+	for _, factory := range w.sortedFactories() {
+		ids := make([]EID, 0, len(live[factory.id]))
+		for id := range live[factory.id] {
+			ids = append(ids, id)
+		}
+
+		sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+		for _, id := range ids {
+			recorded, found := latest.get(factory.id, id)
+			if !found || bytes.Equal(recorded, live[factory.id][id]) || factory.ChangedSince(id, tick) {
+				continue
+			}
+
+			return fmt.Errorf("%w: %s of entity %d", ErrUntrackedChange, factory.Name(), id)
+		}
+	}
+
+	return nil
+}
+
+// rewindEntities removes the live entities which are not alive in the target, and then
+// restores the entity allocator
+func (w *World) rewindEntities(target entitySnapshot, live rollbackState) {
+	for _, id := range w.entities.aliveEntities() {
+		if target.contains(id) {
+			continue
+		}
+
+		_, events, released := w.despawn(id)
+
+		for _, factory := range released {
+			delete(live[factory.id], id)
+			factory.emit(ComponentRemoved, id)
+		}
+
+		events.notify()
+	}
+
+	w.entities.restore(target)
+
+	for _, id := range w.entities.aliveEntities() {
+		w.ComponentFlags.LoadOrStore(id, &bitset.BitSet{})
+	}
+}
+
+// rewindComponents replaces the live component instances which differ from the target state.
+// The target state is the latest recorded state, overridden by the undo state.
+func (w *World) rewindComponents(latest, undo, live rollbackState) error {
+	for _, factory := range w.sortedFactories() {
+		ids := make(map[EID]struct{})
+
+		for _, s := range []rollbackState{undo, live} {
+			for id := range s[factory.id] {
+				ids[id] = struct{}{}
+			}
+		}
+
+		// live instances which are not in the latest state were changed after it was recorded
+		for id, data := range latest[factory.id] {
+			if current, found := live.get(factory.id, id); !found || !bytes.Equal(current, data) {
+				ids[id] = struct{}{}
+			}
+		}
+
+		sorted := make([]EID, 0, len(ids))
+		for id := range ids {
+			sorted = append(sorted, id)
+		}
+
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+		for _, id := range sorted {
+			want, found := undo.get(factory.id, id)
+			if !found {
+				want, found = latest.get(factory.id, id)
+			}
+
+			found = found && want != nil
+			current, alive := live.get(factory.id, id)
+
+			switch {
+			case !found && alive:
+				factory.Remove(id)
+			case found && (!alive || !bytes.Equal(current, want)):
+				c, err := factory.decode(want)
+				if err != nil {
+					return fmt.Errorf("could not decode %s of entity %d: %w", factory.Name(), id, err)
+				}
+
+				factory.set(id, c)
+			}
+		}
+	}
+
+	return nil
+}
+
+// schedulerState yields the time accumulated by the systems towards their next tick
+func (w *World) schedulerState() (elapsed, accumulators map[System]time.Duration) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	elapsed = make(map[System]time.Duration, len(w.elapsed))
+	for s, d := range w.elapsed {
+		elapsed[s] = d
+	}
+
+	accumulators = make(map[System]time.Duration)
+
+	for _, s := range w.Systems {
+		if baseContainer, ok := s.(hasBaseSystem); ok {
+			accumulators[s] = baseContainer.base().accumulated()
+		}
+	}
+
+	return elapsed, accumulators
+}
+
+// restoreScheduler restores the time accumulated by the systems, as recorded in the frame
+func (w *World) restoreScheduler(f *rollbackFrame) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	w.elapsed = make(map[System]time.Duration, len(f.elapsed))
+	for s, d := range f.elapsed {
+		w.elapsed[s] = d
+	}
+
+	for _, s := range w.Systems {
+		if baseContainer, ok := s.(hasBaseSystem); ok {
+			baseContainer.base().setAccumulated(f.accumulators[s])
+		}
+	}
+}
+
+func (s *BaseSystem) accumulated() time.Duration {
+	return s.accumulator
+}
+
+func (s *BaseSystem) setAccumulated(d time.Duration) {
+	s.accumulator = d
+}
@@ -0,0 +1,241 @@
+package akara
+
+import (
+	"sync"
+	"time"
+)
+
+// SchedulingMode declares how the systems of a world are ticked
+type SchedulingMode int
+
+const (
+	// BackgroundScheduling makes every active system tick itself, in its own goroutine,
+	// at the system's tick rate. This is the default.
+	BackgroundScheduling SchedulingMode = iota
+	// DeterministicScheduling makes World.Update tick every active system, one after the other,
+	// on the goroutine that called World.Update. The systems are ticked in the order that they
+	// are stored in the world, and the tick rate of each system is respected by accumulating
+	// the time that passes between world updates.
+	DeterministicScheduling
+	// ParallelScheduling makes World.Update tick every active system, like DeterministicScheduling,
+	// but systems whose component access does not conflict are ticked in parallel.
+	// Systems which conflict are ticked one after the other, in the order that they are stored
+	// in the world. Systems which do not declare their component access are always ticked alone.
+	// See ComponentAccess.
+	ParallelScheduling
+)
+
+// ticksSystems returns true if the world is in charge of ticking its systems
+func (w *World) ticksSystems() bool {
+	return w.scheduling != BackgroundScheduling
+}
+
+// frameDelta yields the amount of time that has passed since the last world update.
+// If a time delta was given explicitly to World.Update, it is used instead.
+func (w *World) frameDelta(timeDelta []time.Duration) time.Duration {
+	now := w.Clock().Now()
+	defer func() { w.lastUpdate = now }()
+
+	if len(timeDelta) > 0 {
+		return timeDelta[0]
+	}
+
+	if w.lastUpdate.IsZero() {
+		return 0
+	}
+
+	return now.Sub(w.lastUpdate)
+}
+
+// scheduledTick is a tick of a system, which is due during the current world update
+type scheduledTick struct {
+	system    System
+	timeDelta time.Duration
+}
+
+// tickSystems ticks all of the active systems, in order, on the calling goroutine.
+// The time delta is scaled by the time scale of each system and accumulated, and the
+// system ticks once its tick period has passed, with a time delta of the whole tick periods
+// that have passed. Systems with a tick frequency of zero tick on every update, and systems
+// with a fixed timestep are advanced on every update. Systems whose time is stopped do not tick.
+func (w *World) tickSystems(timeDelta time.Duration) {
+	w.mutex.Lock()
+	systems := make([]System, len(w.Systems))
+	copy(systems, w.Systems)
+	w.mutex.Unlock()
+
+	due := make([]scheduledTick, 0, len(systems))
+
+	for _, s := range systems {
+		if !s.Active() {
+			continue
+		}
+
+		scale := w.systemTimeScale(s)
+		if scale == 0 {
+			continue
+		}
+
+		elapsed := w.elapsed[s] + scaleDuration(timeDelta, scale)
+		period := s.TickPeriod()
+
+		// systems with a fixed timestep accumulate the time themselves, so they are given the
+		// time of every update, and their interpolation alpha follows every frame
+		if hasFixedTimestep(s) {
+			w.elapsed[s] = 0
+			due = append(due, scheduledTick{system: s, timeDelta: elapsed})
+			continue
+		}
+
+		if elapsed < period {
+			w.elapsed[s] = elapsed
+			continue
+		}
+
+		// the time left over after the last whole tick period counts towards the next tick,
+		// so that the system keeps its tick rate
+		var leftover time.Duration
+		if period > 0 {
+			leftover = elapsed % period
+		}
+
+		w.elapsed[s] = leftover
+
+		due = append(due, scheduledTick{system: s, timeDelta: elapsed - leftover})
+	}
+
+	if w.scheduling == ParallelScheduling {
+		w.tickParallel(due)
+		return
+	}
+
+	for _, t := range due {
+		if w.Halted() {
+			return
+		}
+
+		tickSystem(t.system, t.timeDelta)
+	}
+}
+
+// tickParallel groups the ticks into batches of systems which can safely run at the same time,
+// and runs each batch in parallel. A tick is added to the current batch if it is in the same
+// phase, has no ordering constraint, and has no conflicting component access with every
+// tick already in the batch. Otherwise, a new batch is started.
+func (w *World) tickParallel(due []scheduledTick) {
+	batches := make([][]scheduledTick, 0)
+	accesses := make(map[System]*ComponentAccess, len(due))
+
+	for _, t := range due {
+		accesses[t.system] = systemComponentAccess(t.system)
+	}
+
+	for _, t := range due {
+		last := len(batches) - 1
+
+		if last < 0 || !canJoinBatch(t.system, batches[last], accesses) {
+			batches = append(batches, []scheduledTick{t})
+			continue
+		}
+
+		batches[last] = append(batches[last], t)
+	}
+
+	for _, batch := range batches {
+		if w.Halted() {
+			return
+		}
+
+		if len(batch) == 1 {
+			tickSystem(batch[0].system, batch[0].timeDelta)
+			continue
+		}
+
+		wg := &sync.WaitGroup{}
+		wg.Add(len(batch))
+
+		for _, t := range batch {
+			go func(t scheduledTick) {
+				defer wg.Done()
+				tickSystem(t.system, t.timeDelta)
+			}(t)
+		}
+
+		wg.Wait()
+	}
+}
+
+// canJoinBatch returns true if the system can run in parallel with every system in the batch
+func canJoinBatch(s System, batch []scheduledTick, accesses map[System]*ComponentAccess) bool {
+	access := accesses[s]
+	if access == nil {
+		return false
+	}
+
+	for _, t := range batch {
+		other := accesses[t.system]
+
+		if other == nil || access.ConflictsWith(other) {
+			return false
+		}
+
+		if systemPhase(s) != systemPhase(t.system) || systemsOrdered(s, t.system) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// systemComponentAccess yields the component access declared by the system, or nil
+func systemComponentAccess(s System) *ComponentAccess {
+	if declarer, ok := s.(accessDeclarer); ok {
+		return declarer.ComponentAccess()
+	}
+
+	return nil
+}
+
+// systemsOrdered returns true if there is an ordering constraint between the two systems
+func systemsOrdered(a, b System) bool {
+	return declaresOrder(a, b) || declaresOrder(b, a)
+}
+
+// declaresOrder returns true if the system declares that it runs before or after the other system
+func declaresOrder(s, other System) bool {
+	ordered, ok := s.(orderedSystem)
+	if !ok {
+		return false
+	}
+
+	for _, candidate := range ordered.RunsBefore() {
+		if candidate == other {
+			return true
+		}
+	}
+
+	for _, candidate := range ordered.RunsAfter() {
+		if candidate == other {
+			return true
+		}
+	}
+
+	return false
+}
+
+// hasFixedTimestep returns true if the system uses a fixed timestep. See BaseSystem.SetFixedTimestep.
+func hasFixedTimestep(s System) bool {
+	fixed, ok := s.(interface{ FixedTimestep() bool })
+
+	return ok && fixed.FixedTimestep()
+}
+
+// tickSystem performs a single tick of the system, with the given time delta
+func tickSystem(s System, timeDelta time.Duration) {
+	if baseContainer, ok := s.(hasBaseSystem); ok {
+		baseContainer.base().advance(timeDelta)
+		return
+	}
+
+	s.Tick()
+}
@@ -0,0 +1,393 @@
+package akara
+
+import (
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+
+	"github.com/gravestench/bitset"
+)
+
+// snapshotVersion is the version of the snapshot format written by World.Snapshot
+const snapshotVersion = 1
+
+// Codec creates the encoders and decoders used to write and read world snapshots.
+// See WorldConfig.WithCodec.
+type Codec interface {
+	NewEncoder(w io.Writer) Encoder
+	NewDecoder(r io.Reader) Decoder
+}
+
+// Encoder writes a sequence of values, like a json.Encoder or a gob.Encoder
+type Encoder interface {
+	Encode(v interface{}) error
+}
+
+// Decoder reads a sequence of values, like a json.Decoder or a gob.Decoder
+type Decoder interface {
+	Decode(v interface{}) error
+}
+
+// static check that the codecs implement Codec
+var (
+	_ Codec = JSONCodec{}
+	_ Codec = GobCodec{}
+)
+
+// DefaultCodec is the codec used by worlds which are not configured with a codec
+var DefaultCodec Codec = GobCodec{}
+
+// JSONCodec writes snapshots as a stream of JSON values. This is larger and slower than
+// the GobCodec, but it is human-readable.
+type JSONCodec struct{}
+
+// NewEncoder returns a json.Encoder
+func (JSONCodec) NewEncoder(w io.Writer) Encoder {
+	return json.NewEncoder(w)
+}
+
+// NewDecoder returns a json.Decoder
+func (JSONCodec) NewDecoder(r io.Reader) Decoder {
+	return json.NewDecoder(r)
+}
+
+// GobCodec writes snapshots in the compact binary gob format. This is the default codec.
+type GobCodec struct{}
+
+// NewEncoder returns a gob.Encoder
+func (GobCodec) NewEncoder(w io.Writer) Encoder {
+	return gob.NewEncoder(w)
+}
+
+// NewDecoder returns a gob.Decoder
+func (GobCodec) NewDecoder(r io.Reader) Decoder {
+	return gob.NewDecoder(r)
+}
+
+// snapshotHeader is the first value of a snapshot. It is followed, for each component,
+// by Count pairs of an entity ID and the component instance of that entity.
+type snapshotHeader struct {
+	Version    int
+	Entities   entitySnapshot
+	Components []snapshotComponent
+}
+
+// snapshotComponent declares a component type in a snapshot, by its registered name.
+// The instances of Encoded components are written as the bytes of their ComponentCodec.
+type snapshotComponent struct {
+	Name    string
+	Count   int
+	Encoded bool
+}
+
+// snapshotEntries are the component instances of a single component factory
+type snapshotEntries struct {
+	factory    *ComponentFactory
+	entities   []EID
+	components []Component
+}
+
+// Codec returns the codec used for the snapshots of the world
+func (w *World) Codec() Codec {
+	if w.codec == nil {
+		return DefaultCodec
+	}
+
+	return w.codec
+}
+
+// Snapshot writes all entities and their components to the writer, using the codec of the
+// world. Components are identified by their registered name, so the names must be stable;
+// see RegisterNamedComponent. Components with a codec of their own, see RegisterComponentCodec,
+// are saved as the bytes of that codec. Other components without any exported fields are
+// saved, but their contents are not.
+//
+// Component types which do not claim their name, see RegisterComponent, cannot be saved, and an
+// error wrapping ErrComponentNameConflict is returned.
+//
+// Snapshot should not be called while systems are ticking, otherwise the snapshot may contain
+// a mixture of the state before and after a tick.
+func (w *World) Snapshot(dst io.Writer) error {
+	header := snapshotHeader{
+		Version:  snapshotVersion,
+		Entities: w.entities.snapshot(),
+	}
+
+	entries := make([]snapshotEntries, 0)
+
+	for _, factory := range w.sortedFactories() {
+		e := snapshotEntries{factory: factory}
+
+		factory.Each(func(id EID, c Component) bool {
+			e.entities = append(e.entities, id)
+			e.components = append(e.components, c)
+			return true
+		})
+
+		if len(e.entities) == 0 {
+			continue
+		}
+
+		if !w.claimsName(factory) {
+			const errFmt = "%w: %v cannot be saved, because %q is claimed by another component type"
+			return fmt.Errorf(errFmt, ErrComponentNameConflict, factory.typ, factory.Name())
+		}
+
+		sort.Sort(&e)
+
+		entries = append(entries, e)
+		_, encoded := factory.codec()
+
+		header.Components = append(header.Components, snapshotComponent{
+			Name:    factory.Name(),
+			Count:   len(e.entities),
+			Encoded: encoded,
+		})
+	}
+
+	enc := w.Codec().NewEncoder(dst)
+
+	if err := enc.Encode(header); err != nil {
+		return fmt.Errorf("could not encode snapshot header: %w", err)
+	}
+
+	for cIdx, e := range entries {
+		encoded := header.Components[cIdx].Encoded
+		saveContents := encoded || hasExportedFields(e.factory.typ)
+
+		for idx, id := range e.entities {
+			if err := enc.Encode(id); err != nil {
+				return fmt.Errorf("could not encode %s of entity %d: %w", e.factory.Name(), id, err)
+			}
+
+			if !saveContents {
+				continue
+			}
+
+			var v interface{} = e.components[idx]
+
+			if encoded {
+				codec, _ := e.factory.codec()
+
+				data, err := codec.Marshal(e.components[idx])
+				if err != nil {
+					return fmt.Errorf("could not encode %s of entity %d: %w", e.factory.Name(), id, err)
+				}
+
+				v = data
+			}
+
+			if err := enc.Encode(v); err != nil {
+				return fmt.Errorf("could not encode %s of entity %d: %w", e.factory.Name(), id, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Restore replaces all entities of the world with the entities of a snapshot written by
+// Snapshot, preserving their entity ID's. Every component type in the snapshot must already
+// be registered in the world, with the same name.
+//
+// The existing entities are removed first, and the subscriptions are updated as the
+// components of the snapshot are added. If the snapshot can not be read, an error is
+// returned, and the world is left untouched.
+func (w *World) Restore(src io.Reader) error {
+	header, entries, err := w.readSnapshot(src)
+	if err != nil {
+		return err
+	}
+
+	w.clearEntities()
+	w.entities.restore(header.Entities)
+
+	for _, id := range w.entities.aliveEntities() {
+		w.ComponentFlags.Store(id, &bitset.BitSet{})
+	}
+
+	for _, e := range entries {
+		for idx, id := range e.entities {
+			e.factory.set(id, e.components[idx])
+		}
+	}
+
+	return nil
+}
+
+// Import adds the entities of a snapshot written by Snapshot to the world, alongside the
+// existing entities. This is how a prefab, or a part of another world, is loaded. Every entity
+// of the snapshot is created as a new local entity, and the given entity mapper records which
+// local entity each entity of the snapshot became. Components which implement EntityRemapper
+// are remapped before they are added. If the mapper is nil, a new entity mapper is used.
+//
+// Like Restore, every component type in the snapshot must already be registered in the world,
+// and the world is left untouched if the snapshot can not be read.
+func (w *World) Import(src io.Reader, mapper *EntityMapper) error {
+	header, entries, err := w.readSnapshot(src)
+	if err != nil {
+		return err
+	}
+
+	if mapper == nil {
+		mapper = NewEntityMapper(w)
+	}
+
+	for _, foreign := range header.Entities.aliveEntities() {
+		mapper.Map(foreign)
+	}
+
+	for _, e := range entries {
+		for idx, foreign := range e.entities {
+			local, found := mapper.Local(foreign)
+			if !found {
+				continue // the entity was not alive in the snapshot
+			}
+
+			mapper.remapComponent(e.components[idx])
+			e.factory.set(local, e.components[idx])
+		}
+	}
+
+	return nil
+}
+
+// readSnapshot decodes a snapshot, without applying it to the world
+func (w *World) readSnapshot(src io.Reader) (snapshotHeader, []snapshotEntries, error) {
+	dec := w.Codec().NewDecoder(src)
+
+	var header snapshotHeader
+	if err := dec.Decode(&header); err != nil {
+		return header, nil, fmt.Errorf("could not decode snapshot header: %w", err)
+	}
+
+	if header.Version != snapshotVersion {
+		const errFmt = "%w: version %d, expected version %d"
+		return header, nil, fmt.Errorf(errFmt, ErrSnapshotVersion, header.Version, snapshotVersion)
+	}
+
+	if err := header.Entities.validate(); err != nil {
+		return header, nil, fmt.Errorf("%w: %v", ErrInvalidSnapshot, err)
+	}
+
+	entries := make([]snapshotEntries, 0, len(header.Components))
+
+	for _, sc := range header.Components {
+		id, found := w.GetComponentID(sc.Name)
+		if !found {
+			return header, nil, fmt.Errorf("%w: %q", ErrUnknownComponent, sc.Name)
+		}
+
+		e := snapshotEntries{factory: w.GetComponentFactory(id)}
+		loadContents := sc.Encoded || hasExportedFields(e.factory.typ)
+
+		for idx := 0; idx < sc.Count; idx++ {
+			var eid EID
+			if err := dec.Decode(&eid); err != nil {
+				return header, nil, fmt.Errorf("could not decode %s: %w", sc.Name, err)
+			}
+
+			c, err := decodeSnapshotComponent(dec, e.factory, sc, loadContents)
+			if err != nil {
+				return header, nil, fmt.Errorf("could not decode %s of entity %d: %w", sc.Name, eid, err)
+			}
+
+			e.entities = append(e.entities, eid)
+			e.components = append(e.components, c)
+		}
+
+		entries = append(entries, e)
+	}
+
+	return header, entries, nil
+}
+
+// decodeSnapshotComponent decodes a component instance of a snapshot
+func decodeSnapshotComponent(dec Decoder, factory *ComponentFactory, sc snapshotComponent, load bool) (Component, error) {
+	if !load {
+		return factory.provider(), nil
+	}
+
+	if !sc.Encoded {
+		c := factory.provider()
+		return c, dec.Decode(c)
+	}
+
+	var data []byte
+	if err := dec.Decode(&data); err != nil {
+		return nil, err
+	}
+
+	return factory.decode(data)
+}
+
+// clearEntities removes every entity from the world, immediately
+func (w *World) clearEntities() {
+	w.mutex.Lock()
+	w.entityRemovalQueue = make([]EID, 0)
+	w.mutex.Unlock()
+
+	for _, id := range w.entities.aliveEntities() {
+		_, events, released := w.despawn(id)
+
+		for _, factory := range released {
+			factory.emit(ComponentRemoved, id)
+		}
+
+		events.notify()
+	}
+}
+
+// sortedFactories yields the component factories of the world, ordered by component ID
+func (w *World) sortedFactories() []*ComponentFactory {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	factories := make([]*ComponentFactory, 0, len(w.factories))
+
+	for _, factory := range w.factories {
+		factories = append(factories, factory)
+	}
+
+	sort.Slice(factories, func(i, j int) bool {
+		return factories[i].id < factories[j].id
+	})
+
+	return factories
+}
+
+// hasExportedFields returns false for struct types without any exported fields, which
+// have no contents that can be encoded
+func hasExportedFields(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t.Kind() != reflect.Struct {
+		return true
+	}
+
+	for idx := 0; idx < t.NumField(); idx++ {
+		if t.Field(idx).IsExported() {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (e *snapshotEntries) Len() int {
+	return len(e.entities)
+}
+
+func (e *snapshotEntries) Less(i, j int) bool {
+	return e.entities[i] < e.entities[j]
+}
+
+func (e *snapshotEntries) Swap(i, j int) {
+	e.entities[i], e.entities[j] = e.entities[j], e.entities[i]
+	e.components[i], e.components[j] = e.components[j], e.components[i]
+}
@@ -21,28 +21,63 @@ func NewSubscription(cf *ComponentFilter) *Subscription {
 
 // Subscription is a component filter and a slice of entity ID's for which the filter applies
 type Subscription struct {
-	Filter          *ComponentFilter
-	entityMap             // we use (abuse) the lookup ability of maps for adding/removing EIDs
-	entities        []EID // we sort the map keys when GetEntities is called, only if dirty==true
-	dirty           bool
-	ignoredEntities entityMap
-	mutex           sync.Mutex
+	Filter           *ComponentFilter
+	entityMap              // we use (abuse) the lookup ability of maps for adding/removing EIDs
+	entities         []EID // we sort the map keys when GetEntities is called, only if dirty==true
+	dirty            bool
+	ignoredEntities  entityMap
+	mutex            sync.Mutex
+	refs             int    // how many times the subscription was added to the world
+	world            *World // the world the subscription was added to
+	addedCallbacks   []func(EID)
+	removedCallbacks []func(EID)
 }
 
-// AddEntity adds an entity to the subscription entity map
-func (s *Subscription) AddEntity(id EID) {
-	if _, found := s.entityMap[id]; !found {
-		s.dirty = true
-		s.entityMap[id] = nil
+// AddEntity adds an entity to the subscription entity map.
+// Returns true if the entity was not already in the subscription.
+func (s *Subscription) AddEntity(id EID) bool {
+	if _, found := s.entityMap[id]; found {
+		return false
 	}
+
+	s.dirty = true
+	s.entityMap[id] = nil
+
+	return true
 }
 
-// RemoveEntity removes an entity from the subscription entity map
-func (s *Subscription) RemoveEntity(id EID) {
-	if _, found := s.entityMap[id]; found {
-		s.dirty = true
-		delete(s.entityMap, id)
+// RemoveEntity removes an entity from the subscription entity map.
+// Returns true if the entity was in the subscription.
+func (s *Subscription) RemoveEntity(id EID) bool {
+	if _, found := s.entityMap[id]; !found {
+		return false
 	}
+
+	s.dirty = true
+	delete(s.entityMap, id)
+
+	return true
+}
+
+// OnEntityAdded adds a callback which is invoked with the ID of every entity which starts
+// matching the subscription. The callbacks are invoked by the world, after the world has
+// been unlocked, so they may use the world.
+func (s *Subscription) OnEntityAdded(fn func(EID)) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.addedCallbacks = append(s.addedCallbacks, fn)
+}
+
+// OnEntityRemoved adds a callback which is invoked with the ID of every entity which stops
+// matching the subscription, including entities which are removed from the world, or
+// ignored by the subscription. The callbacks are invoked by the world, after the world
+// has been unlocked, so they may use the world.
+func (s *Subscription) OnEntityRemoved(fn func(EID)) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.removedCallbacks = append(s.removedCallbacks, fn)
 }
 
 // GetEntities returns the entities for the system
@@ -73,10 +108,16 @@ func (s *Subscription) rebuildCache() {
 }
 
 func (s *Subscription) IgnoreEntity(id EID) {
+	var events subscriptionEvents
+
 	s.mutex.Lock()
 	s.ignoredEntities[id] = &empty{}
-	s.RemoveEntity(id)
+	if s.RemoveEntity(id) {
+		events = events.record(s, id, false)
+	}
 	s.mutex.Unlock()
+
+	events.notify()
 }
 
 func (s *Subscription) EntityIsIgnored(id EID) bool {
@@ -0,0 +1,39 @@
+package akara
+
+// subscriptionEvent is an entity which started or stopped matching a subscription
+type subscriptionEvent struct {
+	subscription *Subscription
+	id           EID
+	added        bool
+}
+
+// subscriptionEvents are collected while the world is locked, and the callbacks of the
+// subscriptions are invoked once the world has been unlocked.
+type subscriptionEvents []subscriptionEvent
+
+// record adds the event, if the subscription has callbacks for it.
+// The subscription mutex must be locked by the caller.
+func (e subscriptionEvents) record(s *Subscription, id EID, added bool) subscriptionEvents {
+	if added && len(s.addedCallbacks) == 0 || !added && len(s.removedCallbacks) == 0 {
+		return e
+	}
+
+	return append(e, subscriptionEvent{subscription: s, id: id, added: added})
+}
+
+// notify invokes the callbacks of the subscriptions. Neither the world nor the subscriptions
+// may be locked by the caller.
+func (e subscriptionEvents) notify() {
+	for _, event := range e {
+		event.subscription.mutex.Lock()
+		callbacks := event.subscription.removedCallbacks
+		if event.added {
+			callbacks = event.subscription.addedCallbacks
+		}
+		event.subscription.mutex.Unlock()
+
+		for _, fn := range callbacks {
+			fn(event.id)
+		}
+	}
+}
@@ -42,6 +42,14 @@ type Initializer interface {
 
 type baseSystem interface {
 	Init(*World, func())
+	advance(time.Duration)
+	singleStep(time.Duration)
+	supervision() *systemSupervision
+	setOwner(System)
+	releaseSubscriptions()
+	commandBuffer() *CommandBuffer
+	accumulated() time.Duration
+	setAccumulated(time.Duration)
 }
 
 // hasBaseSystem describes a System that is composed of another type of System.
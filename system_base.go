@@ -1,6 +1,12 @@
 package akara
 
-import "time"
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gravestench/bitset"
+)
 
 type timeManagement struct {
 	TimeDelta        time.Duration
@@ -10,6 +16,18 @@ type timeManagement struct {
 	preTickCallback  func()
 	tickCallback     func()
 	postTickCallback func()
+	fixedTimestep
+	timeScale    float64
+	hasTimeScale bool
+	lastRunTick  uint64
+}
+
+// fixedTimestep holds the state of a system which steps with a constant time delta
+type fixedTimestep struct {
+	fixed            bool
+	maxStepsPerFrame int
+	accumulator      time.Duration
+	alpha            float64
 }
 
 type systemDebugging struct {
@@ -22,11 +40,22 @@ type BaseSystem struct {
 	*World
 	timeManagement
 	systemDebugging
-	active bool
+	systemOrdering
+	systemAccess
+	systemSupervision
+	active      int32      // accessed atomically, because the background ticker reads it
+	tickMutex   sync.Mutex // held while the system ticks, so that World.Step does not race with the background ticker
+	owner       System     // the System composed of this BaseSystem, which is notified of activation changes
+	commands    *CommandBuffer
+	commandSync CommandSync
 }
 
 var DefaultTickRate float64 = 100
 
+// DefaultMaxStepsPerFrame is the default maximum number of fixed timesteps a system will
+// perform for a single tick. See BaseSystem.SetFixedTimestep.
+var DefaultMaxStepsPerFrame = 5
+
 func (s *BaseSystem) base() baseSystem {
 	return s
 }
@@ -42,6 +71,7 @@ func (s *BaseSystem) IsInitialized() bool {
 func (s *BaseSystem) Init(world *World, tickFunc func()) {
 	s.World = world
 	s.tickCallback = tickFunc
+	s.commands = NewCommandBuffer(world)
 
 	if s.tickFrequency == 0 {
 		s.SetTickFrequency(DefaultTickRate)
@@ -50,50 +80,121 @@ func (s *BaseSystem) Init(world *World, tickFunc func()) {
 
 // Active returns true if the system is active, otherwise false
 func (s *BaseSystem) Active() bool {
-	return s.active
+	return atomic.LoadInt32(&s.active) == 1
 }
 
 // Deactivate marks the system inactive and stops it from ticking automatically in the background.
 // The system can be re-activated by calling the Activate method.
 func (s *BaseSystem) Deactivate() {
-	s.active = false
+	wasActive := atomic.SwapInt32(&s.active, 0) == 1
+
+	if hook, ok := s.owner.(DeactivateHook); ok && wasActive {
+		hook.OnDeactivate()
+	}
 }
 
-// Activate calls Tick repeatedly at the target TickRate. This method blocks the thread.
+// Activate calls Tick repeatedly at the target TickRate, in its own goroutine.
+// If the World ticks its systems (see DeterministicScheduling), the system is only marked active.
+// Systems can not be activated once the World is shutting down.
 func (s *BaseSystem) Activate() {
-	s.active = true
+	if s.World.ShuttingDown() {
+		return
+	}
+
+	wasActive := atomic.SwapInt32(&s.active, 1) == 1
 
 	// prevent the system from thinking that the last tick was 1970-01-01...
-	s.lastTick = time.Now()
+	s.tickMutex.Lock()
+	s.lastTick = s.Clock().Now()
+	s.tickMutex.Unlock()
 
-	go s.startTicking()
+	if hook, ok := s.owner.(ActivateHook); ok && !wasActive {
+		hook.OnActivate()
+	}
+
+	if s.World == nil {
+		go s.startTicking()
+		return
+	}
+
+	if s.World.ticksSystems() || !s.World.lifecycle.begin() {
+		return
+	}
+
+	go func() {
+		defer s.World.lifecycle.end()
+
+		s.startTicking()
+	}()
 }
 
 func (s *BaseSystem) startTicking() {
 	// if the TickFrequency is set, try to tick that frequently. Otherwise, we tick as fast as we can
 	if s.TickFrequency() != 0 {
-		ticker := time.NewTicker(s.TickPeriod())
+		period := s.scaledTickPeriod()
+		ticker := s.Clock().NewTicker(period)
 
-		for range ticker.C {
-			if !s.Active() {
+		for {
+			select {
+			case <-ticker.C():
+			case <-s.World.shutdownSignal():
+			}
+
+			if !s.Active() || s.World.ShuttingDown() {
 				break
 			}
 
-			s.Tick()
+			s.backgroundTick()
+
+			// the time scale changes how often the system ticks
+			if next := s.scaledTickPeriod(); next != period {
+				ticker.Stop()
+				period = next
+				ticker = s.Clock().NewTicker(period)
+			}
 		}
 
 		ticker.Stop()
 	} else {
 		for {
-			if !s.Active() {
+			if !s.Active() || s.World.ShuttingDown() {
 				break
 			}
 
-			s.Tick()
+			s.backgroundTick()
 		}
 	}
 }
 
+// backgroundTick ticks the system, unless time is stopped for the system
+func (s *BaseSystem) backgroundTick() {
+	if s.TimeScale() == 0 {
+		s.tickMutex.Lock()
+		defer s.tickMutex.Unlock()
+
+		// the time that passes while time is stopped is not seen by the system
+		s.lastTick = s.Clock().Now()
+		return
+	}
+
+	s.Tick()
+}
+
+// scaledTickPeriod yields the real time between ticks, given the time scale of the system
+func (s *BaseSystem) scaledTickPeriod() time.Duration {
+	scale := s.TimeScale()
+	if scale == 0 {
+		return s.tickPeriod
+	}
+
+	period := time.Duration(float64(s.tickPeriod) / scale)
+	if period <= 0 {
+		period = 1
+	}
+
+	return period
+}
+
 // InjectComponent is shorthand for registering a component and placing the factory in the given destination
 func (s *BaseSystem) InjectComponent(c Component, dst **ComponentFactory) {
 	*dst = s.GetComponentFactory(s.RegisterComponent(c))
@@ -101,17 +202,175 @@ func (s *BaseSystem) InjectComponent(c Component, dst **ComponentFactory) {
 
 // Tick performs a single tick. This is called automatically when the System is Active, but can be called manually
 // to single-step the System, regardless of the System's TickRate.
+//
+// The elapsed time is scaled by the System's TimeScale. If the System uses a fixed timestep, Tick steps
+// the System as many times as the elapsed time allows, which may be zero times.
 func (s *BaseSystem) Tick() {
+	s.tickMutex.Lock()
+	defer s.tickMutex.Unlock()
+
+	var elapsed time.Duration
+
+	if !s.lastTick.IsZero() {
+		elapsed = s.Clock().Since(s.lastTick)
+	}
+
+	s.advance(scaleDuration(elapsed, s.TimeScale()))
+}
+
+// advance moves the system forward by the elapsed time. Without a fixed timestep, this is
+// a single step using the elapsed time as the time delta. With a fixed timestep, the elapsed
+// time is accumulated, and the system is stepped once for every whole tick period.
+func (s *BaseSystem) advance(elapsed time.Duration) {
+	s.lastTick = s.Clock().Now()
+
+	if s.suspended(s.lastTick) {
+		return
+	}
+
+	if !s.fixed || s.tickPeriod == 0 {
+		s.step(elapsed)
+		return
+	}
+
+	s.accumulator += elapsed
+
+	for steps := 0; s.accumulator >= s.tickPeriod; steps++ {
+		if steps >= s.MaxStepsPerFrame() {
+			// drop the backlog, rather than falling further and further behind
+			s.accumulator %= s.tickPeriod
+			break
+		}
+
+		s.step(s.tickPeriod)
+		s.accumulator -= s.tickPeriod
+
+		if s.Restarting() {
+			s.accumulator = 0
+			break
+		}
+	}
+
+	s.alpha = float64(s.accumulator) / float64(s.tickPeriod)
+}
+
+// singleStep performs exactly one step, regardless of the elapsed time. With a fixed timestep,
+// the time delta is the tick period.
+func (s *BaseSystem) singleStep(timeDelta time.Duration) {
+	s.tickMutex.Lock()
+	defer s.tickMutex.Unlock()
+
+	s.lastTick = s.Clock().Now()
+
+	if s.fixed && s.tickPeriod != 0 {
+		timeDelta = s.tickPeriod
+	}
+
+	s.step(timeDelta)
+}
+
+// step performs a single tick, using the given time delta
+func (s *BaseSystem) step(timeDelta time.Duration) {
+	s.TimeDelta = timeDelta
+
+	// changes made while ticking are stamped with a change tick after the last run tick,
+	// and changes made after ticking are stamped with a change tick after this run tick
+	var runTick uint64
+	if s.World != nil {
+		runTick = s.World.advanceChangeTick()
+	}
+
 	s.preTickFunc()
 	s.tickFunc()
 	s.postTickFunc()
 
-	s.TimeDelta = time.Since(s.lastTick)
-	s.lastTick = time.Now()
+	if s.World != nil {
+		s.lastRunTick = runTick
+		s.World.advanceChangeTick()
+	}
+
+	if s.commandSync == SyncAfterTick && s.commands != nil {
+		if err := s.commands.Apply(); err != nil {
+			s.World.handleCommandError(s.owner, err)
+		}
+	}
+
 	s.tickCount += 1
 	s.uptime += s.TimeDelta
 }
 
+// SetFixedTimestep enables or disables the fixed timestep. With a fixed timestep, the elapsed time
+// is accumulated, and the system is stepped zero or more times per tick, always with a TimeDelta
+// equal to the TickPeriod. This keeps things like physics stable, regardless of jitter.
+// The fixed timestep has no effect if the TickFrequency is zero.
+func (s *BaseSystem) SetFixedTimestep(enabled bool) {
+	s.fixed = enabled
+	s.accumulator = 0
+	s.alpha = 0
+}
+
+// FixedTimestep returns true if the system uses a fixed timestep
+func (s *BaseSystem) FixedTimestep() bool {
+	return s.fixed
+}
+
+// SetMaxStepsPerFrame sets the maximum number of fixed timesteps performed for a single tick.
+// If the system falls further behind than this, the excess time is dropped.
+// A value of zero uses DefaultMaxStepsPerFrame.
+func (s *BaseSystem) SetMaxStepsPerFrame(n int) {
+	s.maxStepsPerFrame = n
+}
+
+// MaxStepsPerFrame returns the maximum number of fixed timesteps performed for a single tick
+func (s *BaseSystem) MaxStepsPerFrame() int {
+	if s.maxStepsPerFrame <= 0 {
+		return DefaultMaxStepsPerFrame
+	}
+
+	return s.maxStepsPerFrame
+}
+
+// InterpolationAlpha returns how far the system is between its last fixed timestep and the next,
+// as a value between 0 and 1. Rendering can use this to interpolate between the previous and the
+// current state. This is always zero without a fixed timestep.
+func (s *BaseSystem) InterpolationAlpha() float64 {
+	return s.alpha
+}
+
+// SetTimeScale overrides the time scale of the World for this system. This can be used to keep
+// some systems running in real time while the rest of the World is in slow motion, or while
+// the time scale of the World is 0. The system still stops while the World is paused.
+// See World.SetTimeScale.
+func (s *BaseSystem) SetTimeScale(scale float64) {
+	if scale < 0 {
+		scale = 0
+	}
+
+	s.timeScale = scale
+	s.hasTimeScale = true
+}
+
+// ClearTimeScale removes the time scale override of this system, so that it follows the
+// time scale of the World again.
+func (s *BaseSystem) ClearTimeScale() {
+	s.timeScale = 0
+	s.hasTimeScale = false
+}
+
+// TimeScale returns the effective time scale of this system. This is the time scale set with
+// SetTimeScale, otherwise the time scale of the World, and always 0 while the World is paused.
+func (s *BaseSystem) TimeScale() float64 {
+	if s.World.Paused() {
+		return 0
+	}
+
+	if s.hasTimeScale {
+		return s.timeScale
+	}
+
+	return s.World.TimeScale()
+}
+
 // TickPeriod returns the length of one tick as a time.Duration
 func (s *BaseSystem) TickPeriod() time.Duration {
 	return s.tickPeriod
@@ -168,3 +427,107 @@ func (s *BaseSystem) Uptime() time.Duration {
 func calculateTickPeriod(freq float64) time.Duration {
 	return time.Duration(float64(time.Second) * (1 / freq))
 }
+
+// Phase returns the phase of the world update that this system runs in
+func (s *BaseSystem) Phase() SystemPhase {
+	return s.phase
+}
+
+// SetPhase sets the phase of the world update that this system runs in.
+// This should be set before the system is added to the world.
+func (s *BaseSystem) SetPhase(phase SystemPhase) {
+	s.phase = phase
+}
+
+// Before declares that this system must run before the given systems.
+// This should be declared before the system is added to the world.
+func (s *BaseSystem) Before(systems ...System) {
+	s.before = append(s.before, systems...)
+}
+
+// After declares that this system must run after the given systems.
+// This should be declared before the system is added to the world.
+func (s *BaseSystem) After(systems ...System) {
+	s.after = append(s.after, systems...)
+}
+
+// RunsBefore returns the systems that this system must run before
+func (s *BaseSystem) RunsBefore() []System {
+	return s.before
+}
+
+// RunsAfter returns the systems that this system must run after
+func (s *BaseSystem) RunsAfter() []System {
+	return s.after
+}
+
+// systemAccess holds the declared component access of a BaseSystem
+type systemAccess struct {
+	reads         []Component
+	writes        []Component
+	subscriptions []*Subscription
+}
+
+// AddSubscription adds a subscription to the world (see World.AddSubscription), and records it
+// as a subscription of this system. The components of the subscription filter are
+// considered to be written by this system, unless they are declared with Reads.
+func (s *BaseSystem) AddSubscription(input interface{}) *Subscription {
+	subscription := s.World.AddSubscription(input)
+
+	if subscription != nil {
+		s.subscriptions = append(s.subscriptions, subscription)
+	}
+
+	return subscription
+}
+
+// Reads declares that this system only reads the given components
+func (s *BaseSystem) Reads(components ...Component) {
+	s.reads = append(s.reads, components...)
+}
+
+// Writes declares that this system reads and writes the given components
+func (s *BaseSystem) Writes(components ...Component) {
+	s.writes = append(s.writes, components...)
+}
+
+// ComponentAccess returns the component access of this system. This is derived from the
+// required components of the system's subscriptions, and the components declared with
+// Reads and Writes. Yields nil if the system has not been added to a world, or if the system
+// declares neither subscriptions nor component access, in which case nothing is known about
+// the components that the system uses.
+func (s *BaseSystem) ComponentAccess() *ComponentAccess {
+	if s.World == nil {
+		return nil
+	}
+
+	if len(s.subscriptions) == 0 && len(s.reads) == 0 && len(s.writes) == 0 {
+		return nil
+	}
+
+	access := NewComponentAccess()
+
+	for _, subscription := range s.subscriptions {
+		for _, filterBits := range []*bitset.BitSet{subscription.Filter.Required, subscription.Filter.OneRequired} {
+			if filterBits == nil {
+				continue
+			}
+
+			for _, id := range filterBits.ToIntArray() {
+				access.Write.Set(int(id), true)
+			}
+		}
+	}
+
+	for _, c := range s.reads {
+		id := int(s.RegisterComponent(c))
+		access.Read.Set(id, true)
+		access.Write.Set(id, false)
+	}
+
+	for _, c := range s.writes {
+		access.Write.Set(int(s.RegisterComponent(c)), true)
+	}
+
+	return access
+}
@@ -0,0 +1,163 @@
+package akara
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// FallibleUpdater describes a System whose update can fail. When a System implements
+// FallibleUpdater, UpdateE is called instead of Update, and the errors it returns are
+// returned by World.Update. What happens to the failing System depends on the ErrorPolicy
+// of the world; see WorldConfig.WithErrorPolicy.
+type FallibleUpdater interface {
+	UpdateE() error
+}
+
+// ErrorPolicy declares what the world does when a System fails
+type ErrorPolicy int
+
+const (
+	// ContinueOnError keeps ticking a System which has failed. This is the default.
+	ContinueOnError ErrorPolicy = iota
+	// DeactivateOnError deactivates a System which has failed. The other systems keep ticking.
+	DeactivateOnError
+	// HaltOnError deactivates every System when a System fails. Once halted, World.Update no
+	// longer ticks or activates systems, and returns ErrWorldHalted.
+	HaltOnError
+)
+
+// SystemError is an error returned by a System, along with the name of the System
+type SystemError struct {
+	System System
+	Name   string
+	Err    error
+}
+
+// Error returns the error message, prefixed with the name of the System
+func (e *SystemError) Error() string {
+	return fmt.Sprintf("system %s: %v", e.Name, e.Err)
+}
+
+// Unwrap returns the error returned by the System
+func (e *SystemError) Unwrap() error {
+	return e.Err
+}
+
+// SystemErrors are all of the errors returned by systems since the last world update
+type SystemErrors []*SystemError
+
+// Error returns the error messages of every SystemError
+func (errs SystemErrors) Error() string {
+	messages := make([]string, len(errs))
+
+	for idx := range errs {
+		messages[idx] = errs[idx].Error()
+	}
+
+	return strings.Join(messages, "; ")
+}
+
+// Is returns true if any of the errors is the target error
+func (errs SystemErrors) Is(target error) bool {
+	for idx := range errs {
+		if errors.Is(errs[idx], target) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// As finds the first error which matches the target, and sets the target to that error
+func (errs SystemErrors) As(target interface{}) bool {
+	for idx := range errs {
+		if errors.As(errs[idx], target) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// systemTickFunc yields the function called when the System ticks. Panics are recovered,
+// and handled according to the supervision strategy of the System.
+func (w *World) systemTickFunc(s System) func() {
+	update := s.Update
+
+	if fallible, ok := s.(FallibleUpdater); ok {
+		update = func() {
+			if err := fallible.UpdateE(); err != nil {
+				w.reportSystemError(s, err)
+			}
+		}
+	}
+
+	return func() {
+		defer w.recoverSystemPanic(s)
+
+		update()
+	}
+}
+
+// reportSystemError passes the error to the error handler of the world, records the error,
+// to be returned by the next world update, and applies the error policy of the world.
+func (w *World) reportSystemError(s System, err error) {
+	systemErr := &SystemError{System: s, Name: s.Name(), Err: err}
+
+	w.handleError(systemErr)
+
+	w.mutex.Lock()
+
+	w.systemErrors = append(w.systemErrors, systemErr)
+
+	var deactivate []System
+
+	switch w.errorPolicy {
+	case DeactivateOnError:
+		deactivate = []System{s}
+	case HaltOnError:
+		w.halted = true
+		deactivate = make([]System, len(w.Systems))
+		copy(deactivate, w.Systems)
+	}
+
+	w.mutex.Unlock()
+
+	// not deactivated while locked, in case the systems call back into the world
+	for _, system := range deactivate {
+		system.Deactivate()
+	}
+}
+
+// Halted returns true if the world has been halted because a System failed. See HaltOnError.
+func (w *World) Halted() bool {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	return w.halted
+}
+
+// updateError yields the error to be returned by a world update. Errors returned by systems
+// since the last update take precedence over the configuration error of the world (see Err).
+func (w *World) updateError() error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if len(w.systemErrors) > 0 {
+		errs := w.systemErrors
+		w.systemErrors = nil
+
+		return errs
+	}
+
+	if w.err != nil {
+		return w.err
+	}
+
+	if w.halted {
+		return ErrWorldHalted
+	}
+
+	return nil
+}
@@ -0,0 +1,73 @@
+package akara
+
+// AddedHook describes a System which is notified when it has been added to a World
+type AddedHook interface {
+	OnAdded(*World)
+}
+
+// RemovedHook describes a System which is notified when it has been removed from a World.
+// This is called during the World Update after RemoveSystem, once the System has been
+// deactivated, and before the subscriptions of the System are released.
+type RemovedHook interface {
+	OnRemoved(*World)
+}
+
+// ActivateHook describes a System which is notified when it becomes active.
+// Only systems composed of a BaseSystem are notified.
+type ActivateHook interface {
+	OnActivate()
+}
+
+// DeactivateHook describes a System which is notified when it becomes inactive.
+// Only systems composed of a BaseSystem are notified.
+type DeactivateHook interface {
+	OnDeactivate()
+}
+
+// RemoveSubscription releases the subscription. Because AddSubscription yields the same
+// subscription for identical component filters, the subscription is only removed from the
+// world once it has been released as many times as it was added. Returns true if the
+// subscription was removed from the world.
+func (w *World) RemoveSubscription(s *Subscription) bool {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	idx := -1
+
+	for subIdx := range w.Subscriptions {
+		if w.Subscriptions[subIdx] == s {
+			idx = subIdx
+			break
+		}
+	}
+
+	if idx < 0 {
+		return false
+	}
+
+	if s.refs--; s.refs > 0 {
+		return false
+	}
+
+	w.Subscriptions = append(w.Subscriptions[:idx], w.Subscriptions[idx+1:]...)
+
+	if w.archetypes != nil {
+		w.forgetArchetypeSubscription(s, idx)
+	}
+
+	return true
+}
+
+// releaseSubscriptions releases the subscriptions that the system added with
+// BaseSystem.AddSubscription
+func (s *BaseSystem) releaseSubscriptions() {
+	for _, subscription := range s.subscriptions {
+		s.World.RemoveSubscription(subscription)
+	}
+
+	s.subscriptions = nil
+}
+
+func (s *BaseSystem) setOwner(owner System) {
+	s.owner = owner
+}
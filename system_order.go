@@ -0,0 +1,157 @@
+package akara
+
+import "fmt"
+
+// SystemPhase is a stage of the world update. Systems in an earlier phase always run
+// before systems in a later phase. The default phase is UpdatePhase.
+type SystemPhase int
+
+const (
+	PreUpdatePhase SystemPhase = iota - 1
+	UpdatePhase
+	PostUpdatePhase
+	RenderPhase
+)
+
+// String returns the name of the phase
+func (p SystemPhase) String() string {
+	switch p {
+	case PreUpdatePhase:
+		return "PreUpdate"
+	case UpdatePhase:
+		return "Update"
+	case PostUpdatePhase:
+		return "PostUpdate"
+	case RenderPhase:
+		return "Render"
+	default:
+		return fmt.Sprintf("SystemPhase(%d)", int(p))
+	}
+}
+
+// phasedSystem describes a System which declares the phase that it runs in
+type phasedSystem interface {
+	Phase() SystemPhase
+}
+
+// orderedSystem describes a System which declares which systems it must run before or after
+type orderedSystem interface {
+	RunsBefore() []System
+	RunsAfter() []System
+}
+
+// systemOrdering holds the ordering constraints of a BaseSystem
+type systemOrdering struct {
+	phase  SystemPhase
+	before []System
+	after  []System
+}
+
+func systemPhase(s System) SystemPhase {
+	if phased, ok := s.(phasedSystem); ok {
+		return phased.Phase()
+	}
+
+	return UpdatePhase
+}
+
+// sortSystems sorts the systems by phase, and then topologically by their ordering
+// constraints. Systems which are not constrained keep their relative order. Constraints
+// referring to systems which are not in the given slice are ignored.
+//
+// An error is returned if the constraints form a cycle, or if a system must run before
+// a system in an earlier phase.
+func sortSystems(systems []System) ([]System, error) {
+	index := make(map[System]int, len(systems))
+	for idx, s := range systems {
+		index[s] = idx
+	}
+
+	successors := make([][]int, len(systems))
+	numPredecessors := make([]int, len(systems))
+
+	addEdge := func(from, to System) error {
+		fromIdx, found := index[from]
+		if !found {
+			return nil
+		}
+
+		toIdx, found := index[to]
+		if !found {
+			return nil
+		}
+
+		if fromPhase, toPhase := systemPhase(from), systemPhase(to); fromPhase > toPhase {
+			const errFmt = "%w: %s must run before %s, but the %s phase runs after the %s phase"
+			return fmt.Errorf(errFmt, ErrSystemOrder, from.Name(), to.Name(), fromPhase, toPhase)
+		}
+
+		successors[fromIdx] = append(successors[fromIdx], toIdx)
+		numPredecessors[toIdx]++
+
+		return nil
+	}
+
+	for _, s := range systems {
+		ordered, ok := s.(orderedSystem)
+		if !ok {
+			continue
+		}
+
+		for _, other := range ordered.RunsBefore() {
+			if err := addEdge(s, other); err != nil {
+				return nil, err
+			}
+		}
+
+		for _, other := range ordered.RunsAfter() {
+			if err := addEdge(other, s); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	sorted := make([]System, 0, len(systems))
+	done := make([]bool, len(systems))
+
+	for len(sorted) < len(systems) {
+		// pick the ready system in the earliest phase, preferring the original order
+		next := -1
+
+		for idx := range systems {
+			if done[idx] || numPredecessors[idx] > 0 {
+				continue
+			}
+
+			if next < 0 || systemPhase(systems[idx]) < systemPhase(systems[next]) {
+				next = idx
+			}
+		}
+
+		if next < 0 {
+			return nil, fmt.Errorf("%w: %v", ErrSystemOrder, cycleNames(systems, done))
+		}
+
+		done[next] = true
+		sorted = append(sorted, systems[next])
+
+		for _, successor := range successors[next] {
+			numPredecessors[successor]--
+		}
+	}
+
+	return sorted, nil
+}
+
+// cycleNames yields the names of the systems which could not be sorted
+func cycleNames(systems []System, done []bool) string {
+	names := make([]string, 0)
+
+	for idx := range systems {
+		if !done[idx] {
+			names = append(names, systems[idx].Name())
+		}
+	}
+
+	return fmt.Sprintf("ordering cycle between systems %v", names)
+}
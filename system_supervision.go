@@ -0,0 +1,192 @@
+package akara
+
+import (
+	"fmt"
+	"runtime/debug"
+	"time"
+)
+
+// SupervisionStrategy declares what happens to a System when its update panics.
+// The panic is always recovered, and reported to the error handler of the world.
+// See WorldConfig.WithErrorHandler.
+type SupervisionStrategy int
+
+const (
+	// EscalateOnPanic reports the panic to the world as a SystemError, exactly like an error
+	// returned by a FallibleUpdater. The ErrorPolicy of the world is then applied.
+	// This is the default.
+	EscalateOnPanic SupervisionStrategy = iota
+	// DeactivateOnPanic deactivates the System.
+	DeactivateOnPanic
+	// RestartOnPanic stops ticking the System for a backoff period, and then restarts it: the
+	// time, tick count, and uptime of the System are reset, and the System is initialized again,
+	// so its Init method must be safe to call more than once; see Initializer. The backoff period
+	// doubles with every consecutive panic, until the System ticks without panicking.
+	// See BaseSystem.SetRestartBackoff.
+	RestartOnPanic
+)
+
+var (
+	// DefaultRestartBackoff is how long a System waits before restarting after its first panic
+	DefaultRestartBackoff = 100 * time.Millisecond
+
+	// DefaultMaxRestartBackoff is the longest a System waits before restarting after a panic
+	DefaultMaxRestartBackoff = 10 * time.Second
+)
+
+// PanicError is a panic which was recovered while updating a System
+type PanicError struct {
+	Value interface{}
+	Stack []byte
+}
+
+// Error returns the panic value as an error message
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// systemSupervision holds the supervision strategy of a BaseSystem, and its restart state
+type systemSupervision struct {
+	strategy   SupervisionStrategy
+	backoff    time.Duration
+	maxBackoff time.Duration
+	restarts   int       // consecutive panics
+	restartAt  time.Time // when the system resumes ticking, zero if it is not restarting
+}
+
+// scheduleRestart suspends the system for the backoff period of the current restart
+func (s *systemSupervision) scheduleRestart(now time.Time) {
+	backoff, maxBackoff := s.backoff, s.maxBackoff
+
+	if backoff <= 0 {
+		backoff = DefaultRestartBackoff
+	}
+
+	if maxBackoff <= 0 {
+		maxBackoff = DefaultMaxRestartBackoff
+	}
+
+	for idx := 0; idx < s.restarts && backoff < maxBackoff; idx++ {
+		backoff *= 2
+	}
+
+	if backoff > maxBackoff {
+		backoff = maxBackoff
+	}
+
+	s.restarts++
+	s.restartAt = now.Add(backoff)
+}
+
+// SetSupervision sets what happens to this system when its update panics
+func (s *BaseSystem) SetSupervision(strategy SupervisionStrategy) {
+	s.systemSupervision.strategy = strategy
+}
+
+// Supervision returns what happens to this system when its update panics
+func (s *BaseSystem) Supervision() SupervisionStrategy {
+	return s.systemSupervision.strategy
+}
+
+// SetRestartBackoff sets how long this system waits before restarting after a panic, when it
+// uses RestartOnPanic. The backoff doubles with every consecutive panic, up to the maximum.
+// Zero values use DefaultRestartBackoff and DefaultMaxRestartBackoff.
+func (s *BaseSystem) SetRestartBackoff(backoff, maxBackoff time.Duration) {
+	s.systemSupervision.backoff = backoff
+	s.systemSupervision.maxBackoff = maxBackoff
+}
+
+// Restarting returns true if this system is waiting to restart after a panic
+func (s *BaseSystem) Restarting() bool {
+	return !s.restartAt.IsZero()
+}
+
+func (s *BaseSystem) supervision() *systemSupervision {
+	return &s.systemSupervision
+}
+
+// suspended returns true if the system is waiting to restart after a panic. Once the backoff
+// period has passed, the system is restarted, and starts over without a backlog.
+func (s *BaseSystem) suspended(now time.Time) bool {
+	if s.restartAt.IsZero() {
+		return false
+	}
+
+	if now.Before(s.restartAt) {
+		return true
+	}
+
+	s.restartAt = time.Time{}
+
+	return !s.restart()
+}
+
+// restart resets the time, tick count, and uptime of the system, and initializes the System
+// composed of this BaseSystem again. If initializing panics, the panic is reported to the error
+// handler of the world, the restart is scheduled again, and false is returned.
+func (s *BaseSystem) restart() (restarted bool) {
+	s.timeManagement.TimeDelta = 0
+	s.timeManagement.lastRunTick = 0
+	s.fixedTimestep.accumulator, s.fixedTimestep.alpha = 0, 0
+	s.systemDebugging = systemDebugging{}
+
+	initializer, ok := s.owner.(Initializer)
+	if !ok || s.World == nil {
+		return true
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			err := &PanicError{Value: r, Stack: debug.Stack()}
+			s.World.handleError(&SystemError{System: s.owner, Name: s.owner.Name(), Err: err})
+			s.systemSupervision.scheduleRestart(s.Clock().Now())
+			restarted = false
+		}
+	}()
+
+	initializer.Init(s.World)
+
+	return true
+}
+
+// recoverSystemPanic recovers from a panic in the update of the System, and applies the
+// supervision strategy of the System. This must be deferred.
+func (w *World) recoverSystemPanic(s System) {
+	var supervision *systemSupervision
+
+	if baseContainer, ok := s.(hasBaseSystem); ok {
+		supervision = baseContainer.base().supervision()
+	}
+
+	r := recover()
+	if r == nil {
+		if supervision != nil {
+			supervision.restarts = 0
+		}
+
+		return
+	}
+
+	err := &PanicError{Value: r, Stack: debug.Stack()}
+
+	if supervision == nil || supervision.strategy == EscalateOnPanic {
+		w.reportSystemError(s, err)
+		return
+	}
+
+	w.handleError(&SystemError{System: s, Name: s.Name(), Err: err})
+
+	switch supervision.strategy {
+	case DeactivateOnPanic:
+		s.Deactivate()
+	case RestartOnPanic:
+		supervision.scheduleRestart(w.Clock().Now())
+	}
+}
+
+// handleError passes the error to the error handler of the world, if there is one
+func (w *World) handleError(err error) {
+	if w.errorHandler != nil {
+		w.errorHandler(err)
+	}
+}
@@ -0,0 +1,105 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/gravestench/akara"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestWorld_ArchetypeStorage(t *testing.T) {
+	Convey("Within an ECS world with archetype storage enabled", t, func() {
+		w := akara.NewWorld(akara.NewWorldConfig().WithArchetypeStorage())
+
+		positions := akara.Register[Position](w)
+		velocities := akara.Register[Velocity](w)
+
+		So(positions.StorageKind(), ShouldEqual, akara.ArchetypeStorage)
+
+		movable := w.AddSubscription(w.NewComponentFilter().Require(&Position{}, &Velocity{}))
+
+		e1, e2 := w.NewEntity(), w.NewEntity()
+
+		positions.Add(e1).X = 1
+		positions.Add(e2).X = 2
+		velocities.Add(e2).X = 20
+
+		Convey("Components survive entities moving between archetypes", func() {
+			p, found := positions.Get(e2)
+			So(found, ShouldBeTrue)
+			So(p.X, ShouldEqual, 2)
+
+			v, found := velocities.Get(e2)
+			So(found, ShouldBeTrue)
+			So(v.X, ShouldEqual, 20)
+
+			p, found = positions.Get(e1)
+			So(found, ShouldBeTrue)
+			So(p.X, ShouldEqual, 1)
+
+			_, found = velocities.Get(e1)
+			So(found, ShouldBeFalse)
+		})
+
+		Convey("Subscriptions are updated as entities move between archetypes", func() {
+			So(movable.GetEntities(), ShouldResemble, []akara.EID{e2})
+
+			velocities.Add(e1)
+			So(movable.GetEntities(), ShouldResemble, []akara.EID{e1, e2})
+
+			velocities.Remove(e2)
+			So(movable.GetEntities(), ShouldResemble, []akara.EID{e1})
+
+			p, found := positions.Get(e2)
+			So(found, ShouldBeTrue)
+			So(p.X, ShouldEqual, 2)
+		})
+
+		Convey("New subscriptions are informed about existing entities", func() {
+			positioned := w.AddSubscription(w.NewComponentFilter().Require(&Position{}))
+			So(positioned.GetEntities(), ShouldResemble, []akara.EID{e1, e2})
+
+			unmoving := w.AddSubscription(w.NewComponentFilter().Require(&Position{}).Forbid(&Velocity{}))
+			So(unmoving.GetEntities(), ShouldResemble, []akara.EID{e1})
+		})
+
+		Convey("Archetypes can be queried, and their columns iterated", func() {
+			filter := w.NewComponentFilter().Require(&Position{}).Build()
+			archetypes := w.QueryArchetypes(filter)
+
+			So(len(archetypes), ShouldEqual, 2)
+
+			sum := 0.0
+			for _, a := range archetypes {
+				column := a.Column(positions.ID())
+				So(len(column), ShouldEqual, len(a.Entities()))
+
+				for row := range column {
+					sum += column[row].(*Position).X
+				}
+			}
+
+			So(sum, ShouldEqual, 3)
+		})
+
+		Convey("Removed entities are removed from their archetype", func() {
+			w.RemoveEntity(e2)
+			w.Update()
+
+			So(len(movable.GetEntities()), ShouldEqual, 0)
+			So(positions.Len(), ShouldEqual, 1)
+			So(velocities.Len(), ShouldEqual, 0)
+		})
+
+		Convey("Component factories iterate over every archetype with the component", func() {
+			count := 0
+			positions.Each(func(akara.EID, *Position) bool {
+				count++
+				return true
+			})
+
+			So(count, ShouldEqual, 2)
+		})
+	})
+}
@@ -0,0 +1,98 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gravestench/akara"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type changeDetectionTestSystem struct {
+	akara.BaseSystem
+	positions   *akara.Factory[Position]
+	added       *akara.Subscription
+	changed     *akara.Subscription
+	seenAdded   []akara.EID
+	seenChanged []akara.EID
+}
+
+func (sys *changeDetectionTestSystem) Init(_ *akara.World) {
+	sys.positions = akara.Register[Position](sys.World)
+	sys.added = sys.AddSubscription(sys.NewComponentFilter().Added(&Position{}))
+	sys.changed = sys.AddSubscription(sys.NewComponentFilter().Changed(&Position{}))
+}
+
+func (sys *changeDetectionTestSystem) Update() {
+	sys.seenAdded = sys.added.GetEntitiesSince(sys.LastRunTick())
+	sys.seenChanged = sys.changed.GetEntitiesSince(sys.LastRunTick())
+}
+
+func TestComponentFactory_ChangeDetection(t *testing.T) {
+	Convey("Given a system which detects added and changed components", t, func() {
+		sys := &changeDetectionTestSystem{}
+
+		cfg := akara.NewWorldConfig().
+			WithScheduling(akara.DeterministicScheduling).
+			With(sys)
+
+		w := akara.NewWorld(cfg)
+		sys.SetTickFrequency(0)
+
+		e1, e2 := w.NewEntity(), w.NewEntity()
+		sys.positions.Add(e1)
+		sys.positions.Add(e2)
+
+		Convey("Change filters are distinct from plain filters", func() {
+			plain := w.AddSubscription(w.NewComponentFilter().Require(&Position{}))
+
+			So(plain, ShouldNotEqual, sys.added)
+			So(plain, ShouldNotEqual, sys.changed)
+			So(sys.added, ShouldNotEqual, sys.changed)
+			So(sys.added.GetEntities(), ShouldResemble, []akara.EID{e1, e2})
+		})
+
+		Convey("On its first run, the system sees every component as added and changed", func() {
+			w.Update(time.Millisecond)
+
+			So(sys.seenAdded, ShouldResemble, []akara.EID{e1, e2})
+			So(sys.seenChanged, ShouldResemble, []akara.EID{e1, e2})
+
+			Convey("Untouched components are not seen again", func() {
+				w.Update(time.Millisecond)
+
+				So(sys.seenAdded, ShouldBeEmpty)
+				So(sys.seenChanged, ShouldBeEmpty)
+			})
+
+			Convey("Components marked as changed are seen as changed", func() {
+				sys.positions.MarkChanged(e2)
+				w.Update(time.Millisecond)
+
+				So(sys.seenAdded, ShouldBeEmpty)
+				So(sys.seenChanged, ShouldResemble, []akara.EID{e2})
+			})
+
+			Convey("Mutable access marks components as changed", func() {
+				p, found := sys.positions.GetMut(e1)
+				So(found, ShouldBeTrue)
+				p.X = 1
+
+				w.Update(time.Millisecond)
+
+				So(sys.seenChanged, ShouldResemble, []akara.EID{e1})
+				So(sys.positions.ChangedSince(e1, sys.LastRunTick()), ShouldBeFalse)
+			})
+
+			Convey("New components are seen as added", func() {
+				e3 := w.NewEntity()
+				sys.positions.Add(e3)
+				w.Update(time.Millisecond)
+
+				So(sys.seenAdded, ShouldResemble, []akara.EID{e3})
+				So(sys.seenChanged, ShouldResemble, []akara.EID{e3})
+			})
+		})
+	})
+}
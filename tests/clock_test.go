@@ -0,0 +1,81 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gravestench/akara"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestManualClock(t *testing.T) {
+	Convey("Given a manual clock", t, func() {
+		start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+		clock := akara.NewManualClock(start)
+
+		Convey("Time does not pass on its own", func() {
+			So(clock.Now(), ShouldEqual, start)
+			So(clock.Since(start), ShouldEqual, 0)
+		})
+
+		Convey("Time passes when the clock is advanced", func() {
+			clock.Advance(time.Second)
+
+			So(clock.Now(), ShouldEqual, start.Add(time.Second))
+			So(clock.Since(start), ShouldEqual, time.Second)
+		})
+
+		Convey("Tickers fire when the clock is advanced past their next tick", func() {
+			ticker := clock.NewTicker(10 * time.Millisecond)
+
+			clock.Advance(5 * time.Millisecond)
+			So(len(ticker.C()), ShouldEqual, 0)
+
+			clock.Advance(5 * time.Millisecond)
+			So(len(ticker.C()), ShouldEqual, 1)
+			So(<-ticker.C(), ShouldEqual, start.Add(10*time.Millisecond))
+
+			Convey("Stopped tickers do not fire", func() {
+				ticker.Stop()
+				clock.Advance(time.Second)
+				So(len(ticker.C()), ShouldEqual, 0)
+			})
+		})
+	})
+}
+
+func TestBaseSystem_ManualClock(t *testing.T) {
+	Convey("Given an active System in a World with a manual clock", t, func() {
+		clock := akara.NewManualClock(time.Now())
+		ticked := make(chan time.Duration, 1)
+
+		sys := &MyTestSystem{}
+		sys.SetPostTickCallback(func() {
+			ticked <- sys.TimeDelta
+		})
+
+		w := akara.NewWorld(akara.NewWorldConfig().WithClock(clock).With(sys))
+		w.Update()
+
+		Convey("The System ticks when the clock is advanced by a tick period", func() {
+			// the system creates its ticker in its own goroutine, so keep advancing until it ticks
+			var delta time.Duration
+
+			for ticks := 0; ticks == 0; {
+				clock.Advance(sys.TickPeriod())
+
+				select {
+				case delta = <-ticked:
+					ticks++
+				case <-time.After(time.Millisecond):
+				}
+			}
+
+			So(delta, ShouldBeGreaterThan, 0)
+
+			sys.Deactivate()
+			clock.Advance(sys.TickPeriod())
+		})
+	})
+}
@@ -0,0 +1,296 @@
+package tests
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gravestench/akara"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type spawnerTestSystem struct {
+	*orderedTestSystem
+	positions *akara.Factory[Position]
+	movable   *akara.Subscription
+}
+
+func (sys *spawnerTestSystem) Init(_ *akara.World) {
+	sys.positions = akara.Register[Position](sys.World)
+	sys.movable = sys.AddSubscription(sys.NewComponentFilter().Require(&Position{}, &Velocity{}))
+}
+
+// Update gives a velocity to every entity with a position, and spawns a new entity with a position
+func (sys *spawnerTestSystem) Update() {
+	sys.orderedTestSystem.Update()
+
+	commands := sys.Commands()
+
+	for _, e := range sys.movable.GetEntities() {
+		commands.Despawn(e)
+	}
+
+	e := commands.Spawn()
+	commands.Add(e, &Position{})
+	commands.Set(e, &Velocity{X: float64(len(*sys.log))})
+}
+
+type markerSpawnerTestSystem struct {
+	akara.BaseSystem
+	marker akara.Component
+}
+
+// Update spawns a new entity with the marker component
+func (sys *markerSpawnerTestSystem) Update() {
+	e := sys.Commands().Spawn()
+	sys.Commands().Add(e, sys.marker)
+}
+
+func TestCommandBuffer(t *testing.T) {
+	Convey("Given an ECS World and a command buffer", t, func() {
+		w := akara.NewWorld()
+
+		positions := akara.Register[Position](w)
+		velocities := akara.Register[Velocity](w)
+		movable := w.AddSubscription(w.NewComponentFilter().Require(&Position{}, &Velocity{}))
+
+		commands := akara.NewCommandBuffer(w)
+		placeholder := commands.Spawn()
+
+		commands.
+			Add(placeholder, &Position{}).
+			Set(placeholder, &Velocity{X: 3})
+
+		Convey("The commands are recorded, but not applied", func() {
+			So(w.IsAlive(placeholder), ShouldBeFalse)
+			So(commands.Len(), ShouldEqual, 3)
+			So(positions.Len(), ShouldEqual, 0)
+			So(movable.GetEntities(), ShouldBeEmpty)
+		})
+
+		Convey("The commands are applied in order", func() {
+			commands.Apply()
+
+			So(commands.Len(), ShouldEqual, 0)
+			So(len(movable.GetEntities()), ShouldEqual, 1)
+
+			e := movable.GetEntities()[0]
+			So(w.IsAlive(e), ShouldBeTrue)
+			So(e, ShouldNotEqual, placeholder)
+
+			v, found := velocities.Get(e)
+			So(found, ShouldBeTrue)
+			So(v.X, ShouldEqual, 3)
+
+			Convey("Set replaces an existing component", func() {
+				commands.Set(e, &Velocity{X: 4}).Apply()
+
+				v, _ := velocities.Get(e)
+				So(v.X, ShouldEqual, 4)
+			})
+
+			Convey("Components can be removed", func() {
+				commands.Remove(e, &Position{}).Apply()
+
+				So(movable.GetEntities(), ShouldBeEmpty)
+				So(positions.Len(), ShouldEqual, 0)
+			})
+
+			Convey("Entities can be despawned", func() {
+				commands.Despawn(e).Apply()
+				w.Update()
+
+				So(w.IsAlive(e), ShouldBeFalse)
+			})
+
+			Convey("Placeholders are not valid once the buffer has been applied", func() {
+				err := commands.Despawn(placeholder).Apply()
+				So(errors.Is(err, akara.ErrInvalidCommand), ShouldBeTrue)
+
+				w.Update()
+				So(w.IsAlive(e), ShouldBeTrue)
+			})
+
+			Convey("A buffer with an invalid command applies none of its commands", func() {
+				stale := w.NewEntity()
+				w.RemoveEntity(stale)
+				w.Update()
+
+				other := commands.Spawn()
+				commands.
+					Add(other, &Position{}).
+					Remove(e, &Position{}).
+					Set(stale, &Velocity{})
+
+				So(commands.Len(), ShouldEqual, 4)
+
+				err := commands.Apply()
+				So(errors.Is(err, akara.ErrInvalidCommand), ShouldBeTrue)
+				So(commands.Len(), ShouldEqual, 0)
+
+				So(positions.Len(), ShouldEqual, 1)
+				So(movable.GetEntities(), ShouldResemble, []akara.EID{e})
+			})
+		})
+
+		Convey("Placeholders are unique, and never refer to an entity", func() {
+			other := akara.NewCommandBuffer(w)
+			otherPlaceholder := other.Spawn()
+			So(otherPlaceholder, ShouldNotEqual, placeholder)
+
+			So(commands.Apply(), ShouldBeNil)
+			So(commands.Spawn(), ShouldNotEqual, placeholder)
+
+			Convey("A placeholder of another buffer is not valid", func() {
+				commands.Add(otherPlaceholder, &Velocity{})
+
+				err := commands.Apply()
+				So(errors.Is(err, akara.ErrInvalidCommand), ShouldBeTrue)
+
+				So(other.Apply(), ShouldBeNil)
+				So(velocities.Len(), ShouldEqual, 1)
+			})
+
+			Convey("A placeholder does not collide with the entities of the world", func() {
+				for idx := 0; idx < 10; idx++ {
+					e := w.NewEntity()
+					So(e, ShouldNotEqual, otherPlaceholder)
+
+					w.RemoveEntity(e)
+					w.Update()
+				}
+
+				So(w.IsAlive(otherPlaceholder), ShouldBeFalse)
+			})
+		})
+
+		Convey("Spawned entity ID's are allocated in the order of the commands", func() {
+			other := akara.NewCommandBuffer(w)
+			second := other.Spawn()
+			other.Add(second, &Position{})
+
+			commands.Apply()
+			other.Apply()
+
+			first := movable.GetEntities()[0]
+
+			positions.Each(func(e akara.EID, _ *Position) bool {
+				if e != first {
+					So(akara.EntityIndex(e), ShouldBeGreaterThan, akara.EntityIndex(first))
+				}
+
+				return true
+			})
+		})
+
+		Convey("The world command buffer is applied during world update", func() {
+			e := w.NewEntity()
+			w.Commands().Add(e, &Position{})
+			So(positions.Len(), ShouldEqual, 0)
+
+			w.Update()
+			So(positions.Len(), ShouldEqual, 1)
+		})
+
+		Convey("Commands which cannot be applied during world update are passed to the error handler", func() {
+			errs := make([]error, 0)
+			w := akara.NewWorld(akara.NewWorldConfig().WithErrorHandler(func(err error) {
+				errs = append(errs, err)
+			}))
+
+			e := w.NewEntity()
+			w.RemoveEntity(e)
+			w.Update()
+
+			w.Commands().Add(e, &Position{})
+			So(w.Update(), ShouldBeNil)
+
+			So(len(errs), ShouldEqual, 1)
+			So(errors.Is(errs[0], akara.ErrInvalidCommand), ShouldBeTrue)
+		})
+	})
+
+	Convey("Given systems which make structural changes while iterating", t, func() {
+		log := make([]string, 0)
+
+		a := &spawnerTestSystem{orderedTestSystem: newOrderedTestSystem("a", &log)}
+		b := &spawnerTestSystem{orderedTestSystem: newOrderedTestSystem("b", &log)}
+
+		cfg := akara.NewWorldConfig().
+			WithScheduling(akara.DeterministicScheduling).
+			With(a).
+			With(b)
+
+		w := akara.NewWorld(cfg)
+		a.SetTickFrequency(0)
+		b.SetTickFrequency(0)
+
+		velocities := akara.Register[Velocity](w)
+
+		Convey("The command buffers are applied after the systems tick, in system order", func() {
+			w.Update(time.Millisecond)
+
+			So(a.Commands().Len(), ShouldEqual, 0)
+			So(b.Commands().Len(), ShouldEqual, 0)
+
+			entities := a.movable.GetEntities()
+			So(len(entities), ShouldEqual, 2)
+
+			first, _ := velocities.Get(entities[0])
+			second, _ := velocities.Get(entities[1])
+			So(first.X, ShouldEqual, 1)
+			So(second.X, ShouldEqual, 2)
+
+			Convey("Despawned entities are removed during the same world update", func() {
+				w.Update(time.Millisecond)
+
+				So(a.movable.GetEntities(), ShouldNotContain, entities[0])
+				So(a.movable.GetEntities(), ShouldNotContain, entities[1])
+				So(len(a.movable.GetEntities()), ShouldEqual, 2)
+			})
+		})
+
+		Convey("With SyncAfterTick, the commands are applied as soon as the system ticks", func() {
+			a.SetCommandSync(akara.SyncAfterTick)
+			w.Update(time.Millisecond)
+
+			// b saw the entity spawned by a, and despawned it
+			So(len(a.movable.GetEntities()), ShouldEqual, 1)
+		})
+	})
+
+	Convey("Given systems which spawn entities in parallel", t, func() {
+		a := &markerSpawnerTestSystem{marker: &Position{}}
+		b := &markerSpawnerTestSystem{marker: &Velocity{}}
+
+		a.Writes(&Position{})
+		b.Writes(&Velocity{})
+
+		w := akara.NewWorld(akara.NewWorldConfig().WithScheduling(akara.ParallelScheduling).With(a).With(b))
+		a.SetTickFrequency(0)
+		b.SetTickFrequency(0)
+
+		positions := akara.Register[Position](w)
+		velocities := akara.Register[Velocity](w)
+
+		Convey("The entity ID's are allocated in system order", func() {
+			for idx := 0; idx < 10; idx++ {
+				So(w.Update(time.Millisecond), ShouldBeNil)
+			}
+
+			So(positions.Len(), ShouldEqual, 10)
+			So(velocities.Len(), ShouldEqual, 10)
+
+			positions.Each(func(e akara.EID, _ *Position) bool {
+				So(akara.EntityIndex(e)%2, ShouldEqual, 1)
+				return true
+			})
+
+			velocities.Each(func(e akara.EID, _ *Velocity) bool {
+				So(akara.EntityIndex(e)%2, ShouldEqual, 0)
+				return true
+			})
+		})
+	})
+}
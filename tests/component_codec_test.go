@@ -0,0 +1,302 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/gravestench/akara"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type inventoryItem struct {
+	Name  string
+	Count uint16
+}
+
+type inventoryComponent struct {
+	Owner   string
+	Gold    int64
+	Weight  float32
+	Items   []inventoryItem
+	Tags    map[string]bool
+	Equip   *inventoryItem
+	Grid    [2][2]int8
+	Raw     []byte
+	private int
+}
+
+func (*inventoryComponent) New() akara.Component {
+	return &inventoryComponent{}
+}
+
+// healthComponent has no exported fields, so it encodes itself
+type healthComponent struct {
+	hp int
+}
+
+func (*healthComponent) New() akara.Component {
+	return &healthComponent{}
+}
+
+func (c *healthComponent) MarshalComponent() ([]byte, error) {
+	return []byte(strconv.Itoa(c.hp)), nil
+}
+
+func (c *healthComponent) UnmarshalComponent(data []byte) error {
+	hp, err := strconv.Atoi(string(data))
+	c.hp = hp
+
+	return err
+}
+
+// rankComponent only implements MarshalComponent, so it is encoded with the reflection codec
+type rankComponent struct {
+	Points int
+}
+
+func (*rankComponent) New() akara.Component {
+	return &rankComponent{}
+}
+
+func (c *rankComponent) MarshalComponent() ([]byte, error) {
+	return []byte(strconv.Itoa(c.Points)), nil
+}
+
+type callbackComponent struct {
+	Fn func()
+}
+
+func (*callbackComponent) New() akara.Component {
+	return &callbackComponent{}
+}
+
+// markerListComponent holds values which are encoded as nothing
+type markerListComponent struct {
+	Markers []struct{}
+	Hidden  []struct{ count int }
+	Set     map[struct{}]struct{}
+}
+
+func (*markerListComponent) New() akara.Component {
+	return &markerListComponent{}
+}
+
+// linkedComponent can refer to itself
+type linkedComponent struct {
+	Name string
+	Next *linkedComponent
+}
+
+func (*linkedComponent) New() akara.Component {
+	return &linkedComponent{}
+}
+
+// jsonComponentCodec is a ComponentCodec which encodes components as JSON
+type jsonComponentCodec struct{}
+
+func (jsonComponentCodec) Marshal(c akara.Component) ([]byte, error) {
+	return json.Marshal(c)
+}
+
+func (jsonComponentCodec) Unmarshal(data []byte, c akara.Component) error {
+	return json.Unmarshal(data, c)
+}
+
+func TestReflectCodec(t *testing.T) {
+	Convey("Given a component with exported and unexported fields", t, func() {
+		codec := akara.ReflectCodec{}
+
+		c := &inventoryComponent{
+			Owner:   "deckard",
+			Gold:    -250,
+			Weight:  12.5,
+			Items:   []inventoryItem{{"potion", 3}, {"scroll", 1}},
+			Tags:    map[string]bool{"b": true, "a": false, "c": true},
+			Equip:   &inventoryItem{"sword", 1},
+			Grid:    [2][2]int8{{1, -2}, {3, -4}},
+			Raw:     []byte{0xde, 0xad},
+			private: 42,
+		}
+
+		data, err := codec.Marshal(c)
+		So(err, ShouldBeNil)
+
+		Convey("The exported fields survive a round trip", func() {
+			decoded := &inventoryComponent{}
+			So(codec.Unmarshal(data, decoded), ShouldBeNil)
+
+			c.private = 0
+			So(decoded, ShouldResemble, c)
+		})
+
+		Convey("Equal components are encoded to the same bytes", func() {
+			for i := 0; i < 10; i++ {
+				again, _ := codec.Marshal(c)
+				So(again, ShouldResemble, data)
+			}
+		})
+
+		Convey("Nil and empty values are told apart", func() {
+			empty := &inventoryComponent{Items: []inventoryItem{}}
+			data, err := codec.Marshal(empty)
+			So(err, ShouldBeNil)
+
+			decoded := &inventoryComponent{}
+			So(codec.Unmarshal(data, decoded), ShouldBeNil)
+			So(decoded.Items, ShouldNotBeNil)
+			So(decoded.Tags, ShouldBeNil)
+			So(decoded.Equip, ShouldBeNil)
+		})
+
+		Convey("Truncated data cannot be decoded", func() {
+			err := codec.Unmarshal(data[:len(data)-1], &inventoryComponent{})
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Functions cannot be encoded", func() {
+			_, err := codec.Marshal(&callbackComponent{Fn: func() {}})
+			So(errors.Is(err, akara.ErrUnsupportedComponent), ShouldBeTrue)
+		})
+	})
+
+	Convey("Given a component with values which are encoded as nothing", t, func() {
+		codec := akara.ReflectCodec{}
+
+		c := &markerListComponent{
+			Markers: make([]struct{}, 3),
+			Hidden:  []struct{ count int }{{1}, {2}},
+			Set:     map[struct{}]struct{}{{}: {}},
+		}
+
+		data, err := codec.Marshal(c)
+		So(err, ShouldBeNil)
+
+		Convey("The lengths survive a round trip", func() {
+			decoded := &markerListComponent{}
+			So(codec.Unmarshal(data, decoded), ShouldBeNil)
+
+			So(len(decoded.Markers), ShouldEqual, 3)
+			So(len(decoded.Hidden), ShouldEqual, 2)
+			So(len(decoded.Set), ShouldEqual, 1)
+		})
+	})
+
+	Convey("Given components which refer to other components", t, func() {
+		codec := akara.ReflectCodec{}
+
+		Convey("Shared pointers can be encoded", func() {
+			tail := &linkedComponent{Name: "tail"}
+			c := &linkedComponent{Name: "head", Next: &linkedComponent{Name: "middle", Next: tail}}
+
+			_, err := codec.Marshal(c)
+			So(err, ShouldBeNil)
+
+			_, err = codec.Marshal(&linkedComponent{Name: "again", Next: tail})
+			So(err, ShouldBeNil)
+		})
+
+		Convey("A component which refers to itself cannot be encoded", func() {
+			c := &linkedComponent{Name: "head", Next: &linkedComponent{Name: "tail"}}
+			c.Next.Next = c
+
+			_, err := codec.Marshal(c)
+			So(errors.Is(err, akara.ErrUnsupportedComponent), ShouldBeTrue)
+		})
+	})
+}
+
+func TestComponentFactory_Encode(t *testing.T) {
+	Convey("Given an ECS World with components", t, func() {
+		w := akara.NewWorld(akara.NewWorldConfig())
+		inventories := akara.Register[inventoryComponent](w)
+		healths := akara.Register[healthComponent](w)
+
+		e := w.NewEntity()
+		inventories.Add(e).Gold = 100
+		healths.Add(e).hp = 75
+
+		Convey("Components are encoded with the reflection codec by default", func() {
+			data, err := inventories.Encode(e)
+			So(err, ShouldBeNil)
+
+			other := w.NewEntity()
+			So(inventories.Decode(other, data), ShouldBeNil)
+
+			c, found := inventories.Get(other)
+			So(found, ShouldBeTrue)
+			So(c.Gold, ShouldEqual, 100)
+		})
+
+		Convey("Components which encode themselves use their own methods", func() {
+			data, err := healths.Encode(e)
+			So(err, ShouldBeNil)
+			So(string(data), ShouldEqual, "75")
+
+			So(healths.Decode(e, []byte("10")), ShouldBeNil)
+
+			c, _ := healths.Get(e)
+			So(c.hp, ShouldEqual, 10)
+		})
+
+		Convey("Components which implement only one of the methods use the reflection codec", func() {
+			ranks := akara.Register[rankComponent](w)
+			ranks.Add(e).Points = 42
+
+			data, err := ranks.Encode(e)
+			So(err, ShouldBeNil)
+			So(string(data), ShouldNotEqual, "42")
+
+			other := w.NewEntity()
+			So(ranks.Decode(other, data), ShouldBeNil)
+
+			c, found := ranks.Get(other)
+			So(found, ShouldBeTrue)
+			So(c.Points, ShouldEqual, 42)
+		})
+
+		Convey("A registered codec takes precedence", func() {
+			w.RegisterComponentCodec(&inventoryComponent{}, jsonComponentCodec{})
+
+			data, err := inventories.Encode(e)
+			So(err, ShouldBeNil)
+			So(json.Valid(data), ShouldBeTrue)
+
+			w.RegisterComponentCodec(&inventoryComponent{}, nil)
+			So(w.ComponentCodec(inventories.ID()), ShouldResemble, akara.ReflectCodec{})
+		})
+
+		Convey("Decoding replaces the component and marks it as changed", func() {
+			kinds := make([]akara.ComponentEventKind, 0)
+			healths.Observe(akara.ImmediateDelivery, func(event akara.ComponentEvent) {
+				kinds = append(kinds, event.Kind)
+			})
+
+			So(healths.Decode(e, []byte("1")), ShouldBeNil)
+			So(kinds, ShouldResemble, []akara.ComponentEventKind{akara.ComponentChanged})
+		})
+
+		Convey("Entities without the component cannot be encoded", func() {
+			_, err := inventories.Encode(w.NewEntity())
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Components which encode themselves are saved in snapshots", func() {
+			buf := &bytes.Buffer{}
+			So(w.Snapshot(buf), ShouldBeNil)
+
+			restored := akara.NewWorld(akara.NewWorldConfig())
+			akara.Register[inventoryComponent](restored)
+			restoredHealths := akara.Register[healthComponent](restored)
+
+			So(restored.Restore(buf), ShouldBeNil)
+
+			c, found := restoredHealths.Get(e)
+			So(found, ShouldBeTrue)
+			So(c.hp, ShouldEqual, 75)
+		})
+	})
+}
@@ -0,0 +1,115 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/gravestench/akara"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestComponentFactory_Observe(t *testing.T) {
+	configs := map[string]*akara.WorldConfig{
+		"an ECS world":                        akara.NewWorldConfig(),
+		"an ECS world with archetype storage": akara.NewWorldConfig().WithArchetypeStorage(),
+	}
+
+	for description, cfg := range configs {
+		Convey("Within "+description, t, func() {
+			w := akara.NewWorld(cfg)
+
+			positions := akara.Register[Position](w)
+			velocities := akara.Register[Velocity](w)
+
+			e := w.NewEntity()
+
+			Convey("Immediate factory observers see the changes to their component", func() {
+				events := make([]akara.ComponentEvent, 0)
+				positions.Observe(akara.ImmediateDelivery, func(event akara.ComponentEvent) {
+					events = append(events, event)
+				})
+
+				positions.Add(e)
+				positions.Add(e)
+				velocities.Add(e)
+				positions.MarkChanged(e)
+				positions.Remove(e)
+				positions.MarkChanged(e)
+
+				So(events, ShouldResemble, []akara.ComponentEvent{
+					{Kind: akara.ComponentAdded, Entity: e, Component: positions.ID()},
+					{Kind: akara.ComponentChanged, Entity: e, Component: positions.ID()},
+					{Kind: akara.ComponentRemoved, Entity: e, Component: positions.ID()},
+				})
+			})
+
+			Convey("World observers see the changes to every component", func() {
+				events := make([]akara.ComponentEvent, 0)
+				w.ObserveComponents(akara.ImmediateDelivery, func(event akara.ComponentEvent) {
+					events = append(events, event)
+				})
+
+				positions.Add(e)
+				velocities.Add(e)
+
+				So(events, ShouldResemble, []akara.ComponentEvent{
+					{Kind: akara.ComponentAdded, Entity: e, Component: positions.ID()},
+					{Kind: akara.ComponentAdded, Entity: e, Component: velocities.ID()},
+				})
+
+				Convey("Removing an entity removes all of its components", func() {
+					events = events[:0]
+					w.RemoveEntity(e)
+					w.Update()
+
+					So(events, ShouldResemble, []akara.ComponentEvent{
+						{Kind: akara.ComponentRemoved, Entity: e, Component: positions.ID()},
+						{Kind: akara.ComponentRemoved, Entity: e, Component: velocities.ID()},
+					})
+				})
+			})
+
+			Convey("Deferred observers see the changes during the next world update", func() {
+				factoryEvents := make([]akara.ComponentEvent, 0)
+				worldEvents := make([]akara.ComponentEvent, 0)
+
+				velocities.Observe(akara.DeferredDelivery, func(event akara.ComponentEvent) {
+					factoryEvents = append(factoryEvents, event)
+				})
+
+				w.ObserveComponents(akara.DeferredDelivery, func(event akara.ComponentEvent) {
+					worldEvents = append(worldEvents, event)
+				})
+
+				positions.Add(e)
+				velocities.Add(e)
+				velocities.MarkChanged(e)
+
+				So(factoryEvents, ShouldBeEmpty)
+				So(worldEvents, ShouldBeEmpty)
+
+				w.Update()
+
+				So(factoryEvents, ShouldResemble, []akara.ComponentEvent{
+					{Kind: akara.ComponentAdded, Entity: e, Component: velocities.ID()},
+					{Kind: akara.ComponentChanged, Entity: e, Component: velocities.ID()},
+				})
+
+				So(len(worldEvents), ShouldEqual, 3)
+
+				Convey("Events are only delivered once", func() {
+					w.Update()
+					So(len(worldEvents), ShouldEqual, 3)
+				})
+			})
+		})
+	}
+}
+
+func TestComponentEventKind_String(t *testing.T) {
+	Convey("Component event kinds have names", t, func() {
+		So(akara.ComponentAdded.String(), ShouldEqual, "Added")
+		So(akara.ComponentRemoved.String(), ShouldEqual, "Removed")
+		So(akara.ComponentChanged.String(), ShouldEqual, "Changed")
+	})
+}
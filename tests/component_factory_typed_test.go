@@ -0,0 +1,56 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/gravestench/akara"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestRegister(t *testing.T) {
+	Convey("Within an ECS world", t, func() {
+		w := akara.NewWorld()
+
+		Convey("A component type can be registered to yield a typed factory", func() {
+			positions := akara.Register[Position](w)
+			So(positions, ShouldNotBeNil)
+
+			Convey("The typed factory shares the component ID of the registered component", func() {
+				So(positions.ID(), ShouldEqual, w.RegisterComponent(&Position{}))
+			})
+
+			Convey("Registering the same type again shares the same component factory", func() {
+				So(akara.Register[Position](w).ComponentFactory, ShouldEqual, positions.ComponentFactory)
+			})
+
+			Convey("For a given entity", func() {
+				e := w.NewEntity()
+
+				Convey("The entity does not implicitly have a component", func() {
+					p, found := positions.Get(e)
+					So(found, ShouldBeFalse)
+					So(p, ShouldBeNil)
+				})
+
+				Convey("A component can be added without casting", func() {
+					p := positions.Add(e)
+					p.X, p.Y = 3, 4
+
+					got, found := positions.Get(e)
+					So(found, ShouldBeTrue)
+					So(got, ShouldEqual, p)
+					So(got.X, ShouldEqual, 3)
+				})
+
+				Convey("The component can be removed", func() {
+					positions.Add(e)
+					positions.Remove(e)
+
+					_, found := positions.Get(e)
+					So(found, ShouldBeFalse)
+				})
+			})
+		})
+	})
+}
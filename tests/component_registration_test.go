@@ -0,0 +1,128 @@
+package tests
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/gravestench/akara"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type transform struct{}
+
+func (*transform) New() akara.Component {
+	return &transform{}
+}
+
+type Transform struct{}
+
+func (*Transform) New() akara.Component {
+	return &Transform{}
+}
+
+func TestWorld_RegisterComponent(t *testing.T) {
+	Convey("Within an ECS world", t, func() {
+		w := akara.NewWorld()
+
+		Convey("Distinct component types with similar names have distinct component ID's", func() {
+			lower := w.RegisterComponent(&transform{})
+			upper := w.RegisterComponent(&Transform{})
+
+			So(lower, ShouldNotEqual, upper)
+			So(w.GetComponentFactory(lower), ShouldNotEqual, w.GetComponentFactory(upper))
+		})
+
+		Convey("A component is given a default name using its package path", func() {
+			id := w.RegisterComponent(&Transform{})
+			name := w.GetComponentFactory(id).Name()
+
+			So(name, ShouldEqual, "github.com/gravestench/akara/tests.Transform")
+
+			found, ok := w.GetComponentID(name)
+			So(ok, ShouldBeTrue)
+			So(found, ShouldEqual, id)
+		})
+
+		Convey("A component can be registered with an explicit name", func() {
+			id, err := w.RegisterNamedComponent("transform", &Transform{})
+			So(err, ShouldBeNil)
+			So(w.GetComponentFactory(id).Name(), ShouldEqual, "transform")
+
+			Convey("Registering it again with the same name yields the same component ID", func() {
+				again, err := w.RegisterNamedComponent("transform", &Transform{})
+				So(err, ShouldBeNil)
+				So(again, ShouldEqual, id)
+			})
+
+			Convey("A different type cannot claim the same name", func() {
+				_, err := w.RegisterNamedComponent("transform", &transform{})
+				So(errors.Is(err, akara.ErrComponentNameConflict), ShouldBeTrue)
+			})
+
+			Convey("The component cannot be renamed", func() {
+				_, err := w.RegisterNamedComponent("xform", &Transform{})
+				So(errors.Is(err, akara.ErrComponentNameConflict), ShouldBeTrue)
+			})
+		})
+
+		Convey("A component registered with its default name can be given an explicit name", func() {
+			id := w.RegisterComponent(&Transform{})
+			defaultName := w.GetComponentFactory(id).Name()
+
+			named, err := w.RegisterNamedComponent("transform", &Transform{})
+			So(err, ShouldBeNil)
+			So(named, ShouldEqual, id)
+
+			_, found := w.GetComponentID(defaultName)
+			So(found, ShouldBeFalse)
+		})
+
+		Convey("A component type cannot claim a default name which is already claimed", func() {
+			const name = "github.com/gravestench/akara/tests.Transform"
+
+			_, err := w.RegisterNamedComponent(name, &transform{})
+			So(err, ShouldBeNil)
+
+			_, err = w.TryRegisterComponent(&Transform{})
+			So(errors.Is(err, akara.ErrComponentNameConflict), ShouldBeTrue)
+
+			Convey("RegisterComponent still registers it, without the name", func() {
+				var id akara.ComponentID
+				So(func() { id = w.RegisterComponent(&Transform{}) }, ShouldNotPanic)
+				So(w.RegisterComponent(&Transform{}), ShouldEqual, id)
+
+				claimed, _ := w.GetComponentID(name)
+				So(claimed, ShouldNotEqual, id)
+
+				Convey("And it cannot be saved in a snapshot", func() {
+					akara.Register[Transform](w).Add(w.NewEntity())
+
+					err := w.Snapshot(&bytes.Buffer{})
+					So(errors.Is(err, akara.ErrComponentNameConflict), ShouldBeTrue)
+				})
+
+				Convey("Until it is given a name", func() {
+					_, err := w.RegisterNamedComponent("transform", &Transform{})
+					So(err, ShouldBeNil)
+
+					claimed, _ := w.GetComponentID(name)
+					So(claimed, ShouldNotEqual, id)
+
+					renamed, _ := w.GetComponentID("transform")
+					So(renamed, ShouldEqual, id)
+				})
+			})
+
+			Convey("It can still be registered with an explicit name", func() {
+				id, err := w.RegisterNamedComponent("transform", &Transform{})
+				So(err, ShouldBeNil)
+
+				found, ok := w.GetComponentID(name)
+				So(ok, ShouldBeTrue)
+				So(found, ShouldNotEqual, id)
+			})
+		})
+	})
+}
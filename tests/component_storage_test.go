@@ -0,0 +1,279 @@
+package tests
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"testing"
+
+	"github.com/gravestench/akara"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestComponentFactory_SparseSetStorage(t *testing.T) {
+	Convey("Within an ECS world", t, func() {
+		w := akara.NewWorld()
+
+		Convey("A component type can be registered with sparse set storage", func() {
+			cid := w.RegisterComponentWithStorage(&Position{}, akara.SparseSetStorage)
+			cf := w.GetComponentFactory(cid)
+
+			So(cf.StorageKind(), ShouldEqual, akara.SparseSetStorage)
+
+			entities := []akara.EID{w.NewEntity(), w.NewEntity(), w.NewEntity()}
+			for idx, e := range entities {
+				cf.Add(e).(*Position).X = float64(idx)
+			}
+
+			So(cf.Len(), ShouldEqual, 3)
+
+			Convey("Components can be retrieved for each entity", func() {
+				for idx, e := range entities {
+					c, found := cf.Get(e)
+					So(found, ShouldBeTrue)
+					So(c.(*Position).X, ShouldEqual, float64(idx))
+				}
+			})
+
+			Convey("Removing a component keeps the other components intact", func() {
+				cf.Remove(entities[0])
+
+				_, found := cf.Get(entities[0])
+				So(found, ShouldBeFalse)
+				So(cf.Len(), ShouldEqual, 2)
+
+				for idx, e := range entities[1:] {
+					c, found := cf.Get(e)
+					So(found, ShouldBeTrue)
+					So(c.(*Position).X, ShouldEqual, float64(idx+1))
+				}
+			})
+
+			Convey("Iteration visits every component in the dense array", func() {
+				visited := make(map[akara.EID]float64)
+
+				cf.Each(func(id akara.EID, c akara.Component) bool {
+					visited[id] = c.(*Position).X
+					return true
+				})
+
+				So(len(visited), ShouldEqual, 3)
+				So(visited[entities[2]], ShouldEqual, 2)
+			})
+
+			Convey("A recycled entity index does not inherit the component of a removed entity", func() {
+				w.RemoveEntity(entities[1])
+				w.Update()
+
+				recycled := w.NewEntity()
+				So(akara.EntityIndex(recycled), ShouldEqual, akara.EntityIndex(entities[1]))
+
+				_, found := cf.Get(recycled)
+				So(found, ShouldBeFalse)
+			})
+
+			Convey("Changing the storage kind keeps the existing components", func() {
+				cf.SetStorage(akara.MapStorage)
+
+				So(cf.Len(), ShouldEqual, 3)
+
+				c, found := cf.Get(entities[2])
+				So(found, ShouldBeTrue)
+				So(c.(*Position).X, ShouldEqual, 2)
+			})
+		})
+	})
+}
+
+func TestComponentFactory_LockFreeGet(t *testing.T) {
+	storages := []struct {
+		name string
+		cfg  *akara.WorldConfig
+		kind akara.StorageKind
+	}{
+		{"sparse set storage", akara.NewWorldConfig(), akara.SparseSetStorage},
+		{"archetype storage", akara.NewWorldConfig().WithArchetypeStorage(), akara.ArchetypeStorage},
+	}
+
+	for _, storage := range storages {
+		storage := storage
+
+		Convey("Given a component factory with "+storage.name, t, func() {
+			w := akara.NewWorld(storage.cfg)
+			cf := w.GetComponentFactory(w.RegisterComponentWithStorage(&Position{}, storage.kind))
+			velocities := w.GetComponentFactory(w.RegisterComponent(&Velocity{}))
+
+			So(cf.StorageKind(), ShouldEqual, storage.kind)
+
+			stable := w.NewEntity()
+			cf.Add(stable).(*Position).X = 1
+
+			Convey("Components can be looked up while another goroutine changes the storage", func() {
+				entities := make([]akara.EID, 100)
+				for n := range entities {
+					entities[n] = w.NewEntity()
+				}
+
+				done := make(chan struct{})
+
+				go func() {
+					defer close(done)
+
+					for round := 0; round < 100; round++ {
+						for _, e := range entities {
+							cf.Add(e)
+							velocities.Add(e) // moves the entity between archetypes
+						}
+
+						for _, e := range entities {
+							cf.Remove(e)
+							velocities.Remove(e)
+						}
+					}
+				}()
+
+				found, running := true, true
+
+				for n := 0; running; n++ {
+					select {
+					case <-done:
+						running = false
+					default:
+					}
+
+					c, ok := cf.Get(stable)
+					found = found && ok && c.(*Position).X == 1
+
+					cf.Get(entities[n%len(entities)])
+				}
+
+				So(found, ShouldBeTrue)
+			})
+
+			Convey("Removed components are not found, even with archetype storage", func() {
+				cf.Remove(stable)
+
+				_, found := cf.Get(stable)
+				So(found, ShouldBeFalse)
+
+				e := w.NewEntity()
+				cf.Add(e)
+				w.RemoveEntity(e)
+				w.Update()
+
+				_, found = cf.Get(e)
+				So(found, ShouldBeFalse)
+			})
+		})
+	}
+}
+
+func benchSparseSetGet(i int, b *testing.B) {
+	w := akara.NewWorld()
+	cf := w.GetComponentFactory(w.RegisterComponentWithStorage(&testComponent{}, akara.SparseSetStorage))
+
+	eids := make([]akara.EID, i)
+	for n := range eids {
+		eids[n] = w.NewEntity()
+		cf.Add(eids[n])
+	}
+
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		cf.Get(eids[n%i])
+	}
+}
+
+func benchSparseSetEach(i int, b *testing.B) {
+	w := akara.NewWorld()
+	cf := w.GetComponentFactory(w.RegisterComponentWithStorage(&testComponent{}, akara.SparseSetStorage))
+
+	for n := 0; n < i; n++ {
+		cf.Add(w.NewEntity())
+	}
+
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		cf.Each(func(akara.EID, akara.Component) bool {
+			return true
+		})
+	}
+}
+
+func BenchmarkComponentFactory_SparseSet_GetAll(b *testing.B) {
+	for i := 2; i < 7; i++ {
+		v := int(math.Pow10(i))
+		b.Run(fmt.Sprintf("%d entries", v), func(b *testing.B) {
+			benchSparseSetGet(v, b)
+		})
+	}
+}
+
+func BenchmarkComponentFactory_SparseSet_Each(b *testing.B) {
+	for i := 2; i < 7; i++ {
+		v := int(math.Pow10(i))
+		b.Run(fmt.Sprintf("%d entries", v), func(b *testing.B) {
+			benchSparseSetEach(v, b)
+		})
+	}
+}
+
+// BenchmarkComponentFactory_ParallelGet looks up components from every CPU, while another goroutine
+// keeps adding and removing components of the same type
+func BenchmarkComponentFactory_ParallelGet(b *testing.B) {
+	storages := []struct {
+		name string
+		cfg  *akara.WorldConfig
+		kind akara.StorageKind
+	}{
+		{"map", akara.NewWorldConfig(), akara.MapStorage},
+		{"sparse set", akara.NewWorldConfig(), akara.SparseSetStorage},
+		{"archetype", akara.NewWorldConfig().WithArchetypeStorage(), akara.ArchetypeStorage},
+	}
+
+	for _, storage := range storages {
+		storage := storage
+
+		b.Run(storage.name, func(b *testing.B) {
+			w := akara.NewWorld(storage.cfg)
+			cf := w.GetComponentFactory(w.RegisterComponentWithStorage(&testComponent{}, storage.kind))
+
+			eids := make([]akara.EID, 1000)
+			for n := range eids {
+				eids[n] = w.NewEntity()
+				cf.Add(eids[n])
+			}
+
+			churn := w.NewEntity()
+			done := make(chan struct{})
+			wg := &sync.WaitGroup{}
+			wg.Add(1)
+
+			go func() {
+				defer wg.Done()
+
+				for {
+					select {
+					case <-done:
+						return
+					default:
+						cf.Add(churn)
+						cf.Remove(churn)
+					}
+				}
+			}()
+
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for n := 0; pb.Next(); n++ {
+					cf.Get(eids[n%len(eids)])
+				}
+			})
+			b.StopTimer()
+
+			close(done)
+			wg.Wait()
+		})
+	}
+}
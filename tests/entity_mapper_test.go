@@ -0,0 +1,202 @@
+package tests
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/gravestench/akara"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// followerComponent refers to the entity that it follows
+type followerComponent struct {
+	Target akara.EID
+}
+
+func (*followerComponent) New() akara.Component {
+	return &followerComponent{}
+}
+
+func (c *followerComponent) RemapEntities(m *akara.EntityMapper) {
+	c.Target = m.Remap(c.Target)
+}
+
+func TestEntityMapper(t *testing.T) {
+	Convey("Given an entity mapper for an ECS World", t, func() {
+		w := akara.NewWorld(akara.NewWorldConfig())
+		existing := w.NewEntity()
+		m := akara.NewEntityMapper(w)
+
+		Convey("Foreign entities are mapped to new local entities", func() {
+			local := m.Map(existing) // the same entity ID, but from another world
+
+			So(local, ShouldNotEqual, existing)
+			So(w.IsAlive(local), ShouldBeTrue)
+			So(m.Map(existing), ShouldEqual, local)
+
+			foreign, found := m.Foreign(local)
+			So(found, ShouldBeTrue)
+			So(foreign, ShouldEqual, existing)
+		})
+
+		Convey("Foreign entities which are not mapped are remapped to no entity", func() {
+			So(m.Remap(existing), ShouldEqual, akara.EID(0))
+		})
+
+		Convey("Mappings can be set and forgotten", func() {
+			m.Set(100, existing)
+			So(m.Remap(100), ShouldEqual, existing)
+
+			local, found := m.Forget(100)
+			So(found, ShouldBeTrue)
+			So(local, ShouldEqual, existing)
+			So(m.Len(), ShouldEqual, 0)
+			So(w.IsAlive(existing), ShouldBeTrue)
+		})
+	})
+}
+
+func TestWorld_Import(t *testing.T) {
+	Convey("Given a snapshot of entities which refer to each other", t, func() {
+		newWorld := func() (*akara.World, *akara.Factory[Position], *akara.Factory[followerComponent]) {
+			w := akara.NewWorld(akara.NewWorldConfig())
+			return w, akara.Register[Position](w), akara.Register[followerComponent](w)
+		}
+
+		prefab, positions, followers := newWorld()
+
+		leader, follower := prefab.NewEntity(), prefab.NewEntity()
+		positions.Add(leader).X = 5
+		followers.Add(follower).Target = leader
+
+		buf := &bytes.Buffer{}
+		So(prefab.Snapshot(buf), ShouldBeNil)
+
+		Convey("The snapshot can be imported into a world with colliding entity ID's", func() {
+			w, positions, followers := newWorld()
+			existing := w.NewEntity()
+			positions.Add(existing).X = 1
+
+			m := akara.NewEntityMapper(w)
+			So(w.Import(bytes.NewReader(buf.Bytes()), m), ShouldBeNil)
+
+			localLeader, _ := m.Local(leader)
+			localFollower, _ := m.Local(follower)
+
+			So(existing, ShouldEqual, leader) // the entity ID's collide
+			So(localLeader, ShouldNotEqual, existing)
+
+			p, _ := positions.Get(existing)
+			So(p.X, ShouldEqual, 1)
+
+			p, _ = positions.Get(localLeader)
+			So(p.X, ShouldEqual, 5)
+
+			Convey("And entity references are remapped", func() {
+				f, found := followers.Get(localFollower)
+				So(found, ShouldBeTrue)
+				So(f.Target, ShouldEqual, localLeader)
+			})
+
+			Convey("And the snapshot can be imported again, as new entities", func() {
+				So(w.Import(bytes.NewReader(buf.Bytes()), nil), ShouldBeNil)
+				So(positions.Len(), ShouldEqual, 3)
+				So(followers.Len(), ShouldEqual, 2)
+			})
+		})
+	})
+}
+
+func TestReplica_EntityRemapping(t *testing.T) {
+	Convey("Given a replica of a world with entities which refer to each other", t, func() {
+		server := akara.NewWorld(akara.NewWorldConfig())
+		followers := akara.Register[followerComponent](server)
+		followers.SetNetworked(true)
+
+		client := akara.NewWorld(akara.NewWorldConfig())
+		clientFollowers := akara.Register[followerComponent](client)
+		client.NewEntity() // so that the entity ID's of the worlds differ
+
+		buf := &bytes.Buffer{}
+		replicator := akara.NewReplicator(server)
+		replicator.AddClient(buf)
+		replica := akara.NewReplica(client, buf)
+
+		a, b := server.NewEntity(), server.NewEntity()
+		followers.Add(a).Target = b
+		followers.Add(b).Target = a
+
+		So(replicator.Replicate(), ShouldBeNil)
+		So(replica.Receive(), ShouldBeNil)
+
+		Convey("Entity references point at the local entities", func() {
+			localA, _ := replica.LocalEntity(a)
+			localB, _ := replica.LocalEntity(b)
+			So(localA, ShouldNotEqual, a)
+
+			f, _ := clientFollowers.Get(localA)
+			So(f.Target, ShouldEqual, localB)
+
+			f, _ = clientFollowers.Get(localB)
+			So(f.Target, ShouldEqual, localA)
+		})
+	})
+}
+
+func TestReplica_ForwardReferences(t *testing.T) {
+	Convey("Given a replica of a world with an entity which refers to an entity that is not replicated yet", t, func() {
+		server := akara.NewWorld(akara.NewWorldConfig())
+		followers := akara.Register[followerComponent](server)
+		positions := akara.Register[Position](server)
+		followers.SetNetworked(true)
+		positions.SetNetworked(true)
+
+		client := akara.NewWorld(akara.NewWorldConfig())
+		clientFollowers := akara.Register[followerComponent](client)
+		akara.Register[Position](client)
+		client.NewEntity() // so that the entity ID's of the worlds differ
+
+		buf := &bytes.Buffer{}
+		replicator := akara.NewReplicator(server)
+		replicator.AddClient(buf)
+		replica := akara.NewReplica(client, buf)
+
+		follower, leader := server.NewEntity(), server.NewEntity()
+		followers.Add(follower).Target = leader // the leader has no networked components yet
+
+		So(replicator.Replicate(), ShouldBeNil)
+		So(replica.Receive(), ShouldBeNil)
+
+		localFollower, _ := replica.LocalEntity(follower)
+
+		f, _ := clientFollowers.Get(localFollower)
+		So(f.Target, ShouldEqual, akara.EID(0))
+
+		Convey("The reference is resolved when the entity is spawned in a later packet", func() {
+			positions.Add(leader)
+
+			So(replicator.Replicate(), ShouldBeNil)
+			So(replica.Receive(), ShouldBeNil)
+
+			localLeader, found := replica.LocalEntity(leader)
+			So(found, ShouldBeTrue)
+
+			f, _ := clientFollowers.Get(localFollower)
+			So(f.Target, ShouldEqual, localLeader)
+
+			Convey("And it is cleared when the entity is despawned", func() {
+				positions.Remove(leader)
+
+				So(replicator.Replicate(), ShouldBeNil)
+				So(replica.Receive(), ShouldBeNil)
+
+				client.Update()
+				So(client.IsAlive(localLeader), ShouldBeFalse)
+
+				f, _ := clientFollowers.Get(localFollower)
+				So(f.Target, ShouldEqual, akara.EID(0))
+			})
+		})
+	})
+}
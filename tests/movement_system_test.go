@@ -24,7 +24,13 @@ func Test_ExampleMovementSystem(t *testing.T) {
 		systemTicks += 1
 	})
 
-	cfg := akara.NewWorldConfig().With(sys)
+	clock := akara.NewManualClock(time.Now())
+
+	cfg := akara.NewWorldConfig().
+		WithClock(clock).
+		WithScheduling(akara.DeterministicScheduling).
+		With(sys)
+
 	world := akara.NewWorld(cfg)
 
 	const numEntities = 4
@@ -40,15 +46,14 @@ func Test_ExampleMovementSystem(t *testing.T) {
 
 	world.Update()
 
-	numUpdates := 4
-	loopsWaited := 0
-	for systemTicks < numUpdates {
-		if loopsWaited > 5 {
-			t.Fail()
-		}
+	const numUpdates = 4
+	for idx := 0; idx < numUpdates; idx++ {
+		clock.Advance(sys.TickPeriod())
+		world.Update()
+	}
 
-		time.Sleep(10 * time.Millisecond)
-		loopsWaited += 1
+	if systemTicks != numUpdates {
+		t.Errorf("expected %d system ticks, got %d", numUpdates, systemTicks)
 	}
 }
 
@@ -0,0 +1,114 @@
+package tests
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gravestench/akara"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type accessTestSystem struct {
+	akara.BaseSystem
+	running    *int32
+	maxRunning *int32
+}
+
+func (sys *accessTestSystem) Update() {
+	n := atomic.AddInt32(sys.running, 1)
+	defer atomic.AddInt32(sys.running, -1)
+
+	for {
+		max := atomic.LoadInt32(sys.maxRunning)
+		if n <= max || atomic.CompareAndSwapInt32(sys.maxRunning, max, n) {
+			break
+		}
+	}
+
+	time.Sleep(20 * time.Millisecond) // give the other systems a chance to overlap
+}
+
+func TestWorld_ParallelScheduling(t *testing.T) {
+	Convey("Given an ECS World with parallel scheduling", t, func() {
+		var running, maxRunning int32
+
+		w := akara.NewWorld(akara.NewWorldConfig().WithScheduling(akara.ParallelScheduling))
+
+		newSystem := func() *accessTestSystem {
+			return &accessTestSystem{running: &running, maxRunning: &maxRunning}
+		}
+
+		movement, gravity, render := newSystem(), newSystem(), newSystem()
+
+		Convey("Systems whose component access does not conflict run in parallel", func() {
+			movement.Writes(&Position{})
+			gravity.Writes(&Velocity{})
+			render.Reads(&testComponent{})
+
+			for _, sys := range []*accessTestSystem{movement, gravity, render} {
+				w.AddSystem(sys, true)
+				sys.SetTickFrequency(0)
+			}
+
+			So(w.Update(time.Millisecond), ShouldBeNil)
+			So(maxRunning, ShouldEqual, 3)
+		})
+
+		Convey("Systems which only read the same components run in parallel", func() {
+			movement.Reads(&Position{})
+			render.Reads(&Position{})
+
+			for _, sys := range []*accessTestSystem{movement, render} {
+				w.AddSystem(sys, true)
+				sys.SetTickFrequency(0)
+			}
+
+			So(w.Update(time.Millisecond), ShouldBeNil)
+			So(maxRunning, ShouldEqual, 2)
+		})
+
+		Convey("Systems whose component access conflicts are serialized", func() {
+			for _, sys := range []*accessTestSystem{movement, render} {
+				w.AddSystem(sys, true)
+				sys.SetTickFrequency(0)
+			}
+
+			movement.AddSubscription(w.NewComponentFilter().Require(&Position{}, &Velocity{}))
+			render.Reads(&Position{})
+
+			So(w.Update(time.Millisecond), ShouldBeNil)
+			So(maxRunning, ShouldEqual, 1)
+		})
+
+		Convey("Systems which do not declare their component access are ticked alone", func() {
+			movement.Writes(&Position{})
+			gravity.Writes(&Velocity{})
+
+			for _, sys := range []*accessTestSystem{movement, render, gravity} {
+				So(w.TryAddSystem(sys, true), ShouldBeNil)
+				sys.SetTickFrequency(0)
+			}
+
+			So(render.ComponentAccess(), ShouldBeNil)
+
+			So(w.Update(time.Millisecond), ShouldBeNil)
+			So(maxRunning, ShouldEqual, 1)
+		})
+
+		Convey("Systems with an ordering constraint are serialized", func() {
+			movement.Writes(&Position{})
+			gravity.Writes(&Velocity{})
+			gravity.Before(movement)
+
+			for _, sys := range []*accessTestSystem{movement, gravity} {
+				w.AddSystem(sys, true)
+				sys.SetTickFrequency(0)
+			}
+
+			So(w.Update(time.Millisecond), ShouldBeNil)
+			So(maxRunning, ShouldEqual, 1)
+		})
+	})
+}
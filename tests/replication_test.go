@@ -0,0 +1,254 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+	"net"
+	"testing"
+
+	"github.com/gravestench/akara"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestReplicator(t *testing.T) {
+	Convey("Given an authoritative ECS World and a replica, connected by a pipe", t, func() {
+		server := akara.NewWorld(akara.NewWorldConfig())
+		positions := akara.Register[Position](server)
+		velocities := akara.Register[Velocity](server)
+		positions.SetNetworked(true)
+
+		client := akara.NewWorld(akara.NewWorldConfig())
+		clientPositions := akara.Register[Position](client)
+		clientVelocities := akara.Register[Velocity](client)
+
+		serverConn, clientConn := net.Pipe()
+		defer serverConn.Close()
+		defer clientConn.Close()
+
+		replicator := akara.NewReplicator(server)
+		replicator.AddClient(serverConn)
+		replica := akara.NewReplica(client, clientConn)
+
+		// the pipe is synchronous, so the packets are written while the replica reads them
+		replicate := func() error {
+			errs := make(chan error, 1)
+			go func() { errs <- replicator.Replicate() }()
+
+			if err := replica.Receive(); err != nil {
+				return err
+			}
+
+			return <-errs
+		}
+
+		// immediate observers see the replicated components as soon as they are added or changed
+		observed := make([]Position, 0)
+		clientPositions.Observe(akara.ImmediateDelivery, func(event akara.ComponentEvent) {
+			if p, found := clientPositions.Get(event.Entity); found {
+				observed = append(observed, *p)
+			}
+		})
+
+		e := server.NewEntity()
+		positions.Add(e).X = 10
+		velocities.Add(e).X = 1
+
+		So(replicate(), ShouldBeNil)
+
+		local, found := replica.LocalEntity(e)
+		So(found, ShouldBeTrue)
+
+		Convey("Entities with networked components are spawned on the replica", func() {
+			p, found := clientPositions.Get(local)
+			So(found, ShouldBeTrue)
+			So(p.X, ShouldEqual, 10)
+		})
+
+		Convey("Observers are notified once the replicated component has been decoded", func() {
+			So(observed, ShouldResemble, []Position{{X: 10}})
+		})
+
+		Convey("Components which are not networked are not replicated", func() {
+			_, found := clientVelocities.Get(local)
+			So(found, ShouldBeFalse)
+		})
+
+		Convey("Changed components are replicated", func() {
+			p, _ := positions.Get(e)
+			p.Y = 20
+
+			So(replicate(), ShouldBeNil)
+
+			cp, _ := clientPositions.Get(local)
+			So(*cp, ShouldResemble, Position{X: 10, Y: 20})
+			So(observed, ShouldResemble, []Position{{X: 10}, {X: 10, Y: 20}})
+		})
+
+		Convey("Despawned entities are removed from the replica", func() {
+			server.RemoveEntity(e)
+			server.Update()
+
+			So(replicate(), ShouldBeNil)
+
+			_, found := replica.LocalEntity(e)
+			So(found, ShouldBeFalse)
+
+			client.Update()
+			So(client.IsAlive(local), ShouldBeFalse)
+		})
+	})
+}
+
+func TestReplicator_DeltaCompression(t *testing.T) {
+	Convey("Given an authoritative ECS World replicating to a buffer", t, func() {
+		server := akara.NewWorld(akara.NewWorldConfig())
+		inventories := akara.Register[inventoryComponent](server)
+		tags := akara.Register[testComponent](server)
+		inventories.SetNetworked(true)
+		tags.SetNetworked(true)
+
+		client := akara.NewWorld(akara.NewWorldConfig())
+		clientInventories := akara.Register[inventoryComponent](client)
+		clientTags := akara.Register[testComponent](client)
+
+		buf := &bytes.Buffer{}
+		replicator := akara.NewReplicator(server)
+		replicator.AddClient(buf)
+		replica := akara.NewReplica(client, buf)
+
+		e := server.NewEntity()
+		inv := inventories.Add(e)
+		inv.Owner = "a rather long name, so that a patch is smaller"
+		inv.Items = []inventoryItem{{"potion", 3}, {"scroll", 1}}
+		tags.Add(e)
+
+		So(replicator.Replicate(), ShouldBeNil)
+		fullSize := buf.Len()
+		So(replica.Receive(), ShouldBeNil)
+
+		local, _ := replica.LocalEntity(e)
+
+		Convey("Nothing is sent if nothing changed", func() {
+			So(replicator.Replicate(), ShouldBeNil)
+			So(buf.Len(), ShouldEqual, 0)
+		})
+
+		Convey("Small changes are sent as a patch", func() {
+			inv.Items[0].Count = 2
+
+			So(replicator.Replicate(), ShouldBeNil)
+			So(buf.Len(), ShouldBeLessThan, fullSize/2)
+			So(replica.Receive(), ShouldBeNil)
+
+			c, _ := clientInventories.Get(local)
+			So(c.Items, ShouldResemble, inv.Items)
+			So(c.Owner, ShouldEqual, inv.Owner)
+		})
+
+		Convey("Removed components are removed from the replica", func() {
+			tags.Remove(e)
+
+			So(replicator.Replicate(), ShouldBeNil)
+			So(replica.Receive(), ShouldBeNil)
+
+			_, found := clientTags.Get(local)
+			So(found, ShouldBeFalse)
+
+			_, found = clientInventories.Get(local)
+			So(found, ShouldBeTrue)
+		})
+
+		Convey("A replica without the component type reports it, but applies the rest", func() {
+			other := akara.NewWorld(akara.NewWorldConfig())
+			otherTags := akara.Register[testComponent](other)
+
+			replicator.AddClient(buf)
+			So(replicator.Replicate(), ShouldBeNil)
+
+			err := akara.NewReplica(other, buf).Receive()
+			So(errors.Is(err, akara.ErrUnknownComponent), ShouldBeTrue)
+			So(otherTags.Len(), ShouldEqual, 1)
+		})
+
+		Convey("Packets which are out of order are rejected", func() {
+			inv.Gold = 1
+			So(replicator.Replicate(), ShouldBeNil)
+
+			err := akara.NewReplica(client, buf).Receive()
+			So(errors.Is(err, akara.ErrReplicationOutOfSync), ShouldBeTrue)
+		})
+	})
+}
+
+func TestReplica_MalformedPatches(t *testing.T) {
+	Convey("Given a replica which has received a component", t, func() {
+		server := akara.NewWorld(akara.NewWorldConfig())
+		positions := akara.Register[Position](server)
+		positions.SetNetworked(true)
+
+		client := akara.NewWorld(akara.NewWorldConfig())
+		clientPositions := akara.Register[Position](client)
+
+		buf := &bytes.Buffer{}
+		replicator := akara.NewReplicator(server)
+		replicator.AddClient(buf)
+		replica := akara.NewReplica(client, buf)
+
+		e := server.NewEntity()
+		positions.Add(e).X = 10
+
+		So(replicator.Replicate(), ShouldBeNil)
+		So(replica.Receive(), ShouldBeNil)
+
+		// uvarints encodes the values as a sequence of uvarints
+		uvarints := func(values ...uint64) []byte {
+			data := make([]byte, 0)
+			for _, v := range values {
+				var scratch [binary.MaxVarintLen64]byte
+				data = append(data, scratch[:binary.PutUvarint(scratch[:], v)]...)
+			}
+
+			return data
+		}
+
+		// receivePatch sends the second packet by hand, with a patch of the component
+		receivePatch := func(patch []byte) error {
+			// sequence, declarations, spawns, despawns, and a single update
+			payload := uvarints(2, 0, 0, 0, 1, uint64(e), uint64(positions.ID()))
+			payload = append(payload, 1) // a patch
+			payload = append(append(payload, uvarints(uint64(len(patch)))...), patch...)
+
+			buf.Write(append(uvarints(uint64(len(payload))), payload...))
+
+			return replica.Receive()
+		}
+
+		malformed := []struct {
+			name  string
+			patch []byte
+		}{
+			{"a truncated patch", uvarints(1, 0)},
+			{"a skip past the end", uvarints(1, math.MaxUint64, 0)},
+			{"a run past the end", append(uvarints(1, 0, 100), make([]byte, 100)...)},
+			{"a skip which overflows", uvarints(2, 1, 0, math.MaxUint64, 0)},
+			{"a patch with bytes left over", uvarints(0, 0)},
+		}
+
+		for _, m := range malformed {
+			patch := m.patch
+
+			Convey("Receiving "+m.name+" fails without a panic", func() {
+				var err error
+				So(func() { err = receivePatch(patch) }, ShouldNotPanic)
+				So(errors.Is(err, akara.ErrReplicationOutOfSync), ShouldBeTrue)
+
+				local, _ := replica.LocalEntity(e)
+				p, _ := clientPositions.Get(local)
+				So(p.X, ShouldEqual, 10)
+			})
+		}
+	})
+}
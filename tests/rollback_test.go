@@ -0,0 +1,315 @@
+package tests
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gravestench/akara"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// integratorTestSystem adds the velocity of every movable entity to its position, once per tick
+type integratorTestSystem struct {
+	akara.BaseSystem
+	positions  *akara.Factory[Position]
+	velocities *akara.Factory[Velocity]
+	movable    *akara.Subscription
+}
+
+func (sys *integratorTestSystem) Name() string {
+	return "integrator"
+}
+
+func (sys *integratorTestSystem) Init(w *akara.World) {
+	sys.positions = akara.Register[Position](w)
+	sys.velocities = akara.Register[Velocity](w)
+	sys.movable = w.AddSubscription(w.NewComponentFilter().Require(&Position{}, &Velocity{}))
+	sys.SetTickFrequency(0)
+}
+
+func (sys *integratorTestSystem) Update() {
+	for _, id := range sys.movable.GetEntities() {
+		p, _ := sys.positions.GetMut(id)
+		v, _ := sys.velocities.Get(id)
+
+		p.X += v.X
+		p.Y += v.Y
+	}
+}
+
+var errTestCodec = errors.New("test codec failed")
+
+type scoreComponent struct {
+	Points int
+}
+
+func (*scoreComponent) New() akara.Component {
+	return &scoreComponent{}
+}
+
+// countingComponentCodec counts how many components it encodes, and fails if told to
+type countingComponentCodec struct {
+	akara.ReflectCodec
+	encoded int
+	fail    bool
+}
+
+func (c *countingComponentCodec) Marshal(component akara.Component) ([]byte, error) {
+	if c.fail {
+		return nil, errTestCodec
+	}
+
+	c.encoded++
+
+	return c.ReflectCodec.Marshal(component)
+}
+
+func newRollbackTestWorld(frames int) (*akara.World, *integratorTestSystem) {
+	sys := &integratorTestSystem{}
+
+	cfg := akara.NewWorldConfig().
+		WithScheduling(akara.DeterministicScheduling).
+		WithRollback(frames).
+		With(sys)
+
+	return akara.NewWorld(cfg), sys
+}
+
+func TestWorld_Rewind(t *testing.T) {
+	Convey("Given an ECS World with rollback enabled", t, func() {
+		w, sys := newRollbackTestWorld(8)
+		So(w.Update(time.Millisecond), ShouldBeNil) // frame 1, the system is started
+
+		e := w.NewEntity()
+		sys.positions.Add(e)
+		sys.velocities.Add(e).X = 1
+
+		for i := 0; i < 3; i++ {
+			So(w.Update(time.Millisecond), ShouldBeNil)
+		}
+
+		So(w.Frame(), ShouldEqual, 4)
+
+		p, _ := sys.positions.Get(e)
+		So(p.X, ShouldEqual, 3)
+
+		Convey("Components are restored to the state after the given frame", func() {
+			So(w.Rewind(2), ShouldBeNil)
+			So(w.Frame(), ShouldEqual, 2)
+
+			p, _ := sys.positions.Get(e)
+			So(p.X, ShouldEqual, 1)
+
+			Convey("And the world resumes from there", func() {
+				So(w.Update(time.Millisecond), ShouldBeNil)
+
+				p, _ := sys.positions.Get(e)
+				So(p.X, ShouldEqual, 2)
+				So(w.Frame(), ShouldEqual, 3)
+			})
+		})
+
+		Convey("Entities are restored with the same entity ID's", func() {
+			spawned := w.NewEntity()
+			sys.positions.Add(spawned)
+			w.RemoveEntity(e)
+			So(w.Update(time.Millisecond), ShouldBeNil)
+			So(w.IsAlive(e), ShouldBeFalse)
+
+			So(w.Rewind(4), ShouldBeNil)
+
+			So(w.IsAlive(e), ShouldBeTrue)
+			So(w.IsAlive(spawned), ShouldBeFalse)
+			So(sys.movable.GetEntities(), ShouldResemble, []akara.EID{e})
+
+			p, _ := sys.positions.Get(e)
+			So(p.X, ShouldEqual, 3)
+		})
+
+		Convey("Changes made since the latest frame are undone", func() {
+			p, _ := sys.positions.GetMut(e)
+			p.X = 100
+			sys.velocities.Remove(e)
+
+			So(w.Rewind(4), ShouldBeNil)
+
+			p, _ = sys.positions.Get(e)
+			So(p.X, ShouldEqual, 3)
+
+			_, found := sys.velocities.Get(e)
+			So(found, ShouldBeTrue)
+		})
+
+		Convey("Components which were changed without MarkChanged cannot be rewound", func() {
+			v, _ := sys.velocities.Get(e)
+			v.X = 10 // not marked as changed
+
+			So(w.Update(time.Millisecond), ShouldBeNil)
+
+			err := w.Rewind(2)
+			So(errors.Is(err, akara.ErrUntrackedChange), ShouldBeTrue)
+			So(w.Frame(), ShouldEqual, 5)
+
+			p, _ := sys.positions.Get(e)
+			So(p.X, ShouldEqual, 13)
+
+			v, _ = sys.velocities.Get(e)
+			So(v.X, ShouldEqual, 10)
+
+			Convey("Until the change is marked", func() {
+				sys.velocities.MarkChanged(e)
+				So(w.Update(time.Millisecond), ShouldBeNil)
+
+				So(w.Rewind(2), ShouldBeNil)
+
+				p, _ := sys.positions.Get(e)
+				So(p.X, ShouldEqual, 1)
+
+				v, _ := sys.velocities.Get(e)
+				So(v.X, ShouldEqual, 1)
+			})
+		})
+
+		Convey("Only the components which differ are replaced", func() {
+			events := make([]akara.ComponentEvent, 0)
+			w.ObserveComponents(akara.ImmediateDelivery, func(event akara.ComponentEvent) {
+				events = append(events, event)
+			})
+
+			So(w.Rewind(2), ShouldBeNil)
+
+			So(events, ShouldResemble, []akara.ComponentEvent{
+				{Kind: akara.ComponentChanged, Entity: e, Component: sys.positions.ID()},
+			})
+		})
+
+		Convey("Frames which are no longer recorded are unavailable", func() {
+			for i := 0; i < 10; i++ {
+				So(w.Update(time.Millisecond), ShouldBeNil)
+			}
+
+			oldest, latest, ok := w.RollbackFrames()
+			So(ok, ShouldBeTrue)
+			So(oldest, ShouldEqual, 7)
+			So(latest, ShouldEqual, 14)
+
+			So(errors.Is(w.Rewind(6), akara.ErrRollbackUnavailable), ShouldBeTrue)
+			So(errors.Is(w.Rewind(15), akara.ErrRollbackUnavailable), ShouldBeTrue)
+			So(w.Rewind(7), ShouldBeNil)
+		})
+	})
+
+	Convey("Given an ECS World with background scheduling", t, func() {
+		w := akara.NewWorld(akara.NewWorldConfig().WithRollback(8))
+
+		Convey("Rollback is unavailable", func() {
+			So(errors.Is(w.Err(), akara.ErrRollbackUnavailable), ShouldBeTrue)
+			So(errors.Is(w.Rewind(0), akara.ErrRollbackUnavailable), ShouldBeTrue)
+		})
+	})
+}
+
+func TestWorld_RecordFrame(t *testing.T) {
+	Convey("Given an ECS World with rollback enabled, and a component with a counting codec", t, func() {
+		w, _ := newRollbackTestWorld(8)
+
+		codec := &countingComponentCodec{}
+		w.RegisterComponentCodec(&scoreComponent{}, codec)
+		scores := akara.Register[scoreComponent](w)
+
+		e := w.NewEntity()
+		scores.Add(e).Points = 1
+
+		So(w.Update(time.Millisecond), ShouldBeNil)
+		So(codec.encoded, ShouldEqual, 1)
+
+		Convey("Components which did not change are not encoded again", func() {
+			for i := 0; i < 3; i++ {
+				So(w.Update(time.Millisecond), ShouldBeNil)
+			}
+
+			So(codec.encoded, ShouldEqual, 1)
+
+			score, _ := scores.GetMut(e)
+			score.Points = 2
+
+			So(w.Update(time.Millisecond), ShouldBeNil)
+			So(codec.encoded, ShouldEqual, 2)
+		})
+
+		Convey("A frame which cannot be encoded is not recorded, and the earlier frames are kept", func() {
+			codec.fail = true
+
+			score, _ := scores.GetMut(e)
+			score.Points = 2
+
+			So(errors.Is(w.Update(time.Millisecond), errTestCodec), ShouldBeTrue)
+			So(w.Frame(), ShouldEqual, 2)
+
+			codec.fail = false
+			So(w.Update(time.Millisecond), ShouldBeNil)
+
+			oldest, latest, ok := w.RollbackFrames()
+			So(ok, ShouldBeTrue)
+			So(oldest, ShouldEqual, 0)
+			So(latest, ShouldEqual, 3)
+
+			So(errors.Is(w.Rewind(2), akara.ErrRollbackUnavailable), ShouldBeTrue)
+
+			So(w.Rewind(1), ShouldBeNil)
+
+			score, _ = scores.Get(e)
+			So(score.Points, ShouldEqual, 1)
+		})
+	})
+}
+
+func TestWorld_Resimulate(t *testing.T) {
+	Convey("Given two ECS Worlds simulating the same entity", t, func() {
+		newWorld := func() (*akara.World, *integratorTestSystem, akara.EID) {
+			w, sys := newRollbackTestWorld(16)
+			So(w.Update(time.Millisecond), ShouldBeNil)
+
+			e := w.NewEntity()
+			sys.positions.Add(e)
+			sys.velocities.Add(e).X = 1
+
+			return w, sys, e
+		}
+
+		predicted, predictedSys, e := newWorld()
+		actual, actualSys, _ := newWorld()
+
+		// the actual world knows that the velocity changes during frame 3
+		for actual.Frame() < 8 {
+			if actual.Frame() == 3 {
+				v, _ := actualSys.velocities.GetMut(e)
+				v.X = 5
+			}
+
+			So(actual.Update(time.Millisecond), ShouldBeNil)
+		}
+
+		for predicted.Frame() < 8 {
+			So(predicted.Update(time.Millisecond), ShouldBeNil)
+		}
+
+		Convey("Resimulating with the corrected input converges on the actual state", func() {
+			err := predicted.Resimulate(3, time.Millisecond, func(frame uint64) {
+				if frame == 3 {
+					v, _ := predictedSys.velocities.GetMut(e)
+					v.X = 5
+				}
+			})
+
+			So(err, ShouldBeNil)
+			So(predicted.Frame(), ShouldEqual, 8)
+
+			p, _ := predictedSys.positions.Get(e)
+			expected, _ := actualSys.positions.Get(e)
+			So(p, ShouldResemble, expected)
+		})
+	})
+}
@@ -0,0 +1,205 @@
+package tests
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gravestench/akara"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type orderedTestSystem struct {
+	akara.BaseSystem
+	name   string
+	log    *[]string
+	deltas []time.Duration
+}
+
+func (sys *orderedTestSystem) Name() string {
+	return sys.name
+}
+
+func (sys *orderedTestSystem) Update() {
+	*sys.log = append(*sys.log, sys.name)
+	sys.deltas = append(sys.deltas, sys.TimeDelta)
+}
+
+func newOrderedTestSystem(name string, log *[]string) *orderedTestSystem {
+	return &orderedTestSystem{name: name, log: log}
+}
+
+func TestWorld_DeterministicScheduling(t *testing.T) {
+	Convey("Given an ECS World with deterministic scheduling", t, func() {
+		log := make([]string, 0)
+
+		a := newOrderedTestSystem("a", &log)
+		b := newOrderedTestSystem("b", &log)
+		c := newOrderedTestSystem("c", &log)
+
+		cfg := akara.NewWorldConfig().
+			WithScheduling(akara.DeterministicScheduling).
+			With(a).
+			With(b).
+			With(c)
+
+		w := akara.NewWorld(cfg)
+
+		for _, sys := range []*orderedTestSystem{a, b, c} {
+			sys.SetTickFrequency(0) // tick on every world update
+		}
+
+		Convey("Systems do not tick on their own", func() {
+			time.Sleep(20 * time.Millisecond)
+			So(len(log), ShouldEqual, 0)
+		})
+
+		Convey("Systems are ticked by the world update, in order", func() {
+			So(w.Update(time.Millisecond), ShouldBeNil)
+			So(w.Update(time.Millisecond), ShouldBeNil)
+
+			So(log, ShouldResemble, []string{"a", "b", "c", "a", "b", "c"})
+		})
+
+		Convey("Systems see the time delta given to the world update", func() {
+			w.Update(16 * time.Millisecond)
+
+			So(a.deltas, ShouldResemble, []time.Duration{16 * time.Millisecond})
+		})
+
+		Convey("Inactive systems are not ticked", func() {
+			w.Update(time.Millisecond)
+			b.Deactivate()
+			w.Update(time.Millisecond)
+
+			So(log, ShouldResemble, []string{"a", "b", "c", "a", "c"})
+		})
+
+		Convey("The tick frequency of a system is respected", func() {
+			b.SetTickFrequency(10) // once every 100ms
+
+			w.Update(60 * time.Millisecond)
+			So(len(b.deltas), ShouldEqual, 0)
+
+			w.Update(60 * time.Millisecond)
+			So(b.deltas, ShouldResemble, []time.Duration{100 * time.Millisecond})
+
+			w.Update(60 * time.Millisecond)
+			So(len(b.deltas), ShouldEqual, 1)
+			So(len(a.deltas), ShouldEqual, 3)
+
+			Convey("The time left over after a tick counts towards the next tick", func() {
+				for i := 0; i < 7; i++ {
+					w.Update(60 * time.Millisecond)
+				}
+
+				// 600ms have passed, at 10 ticks per second
+				So(len(b.deltas), ShouldEqual, 6)
+			})
+		})
+	})
+}
+
+func TestWorld_SystemOrdering(t *testing.T) {
+	Convey("Given an ECS World with deterministic scheduling", t, func() {
+		log := make([]string, 0)
+
+		render := newOrderedTestSystem("render", &log)
+		collision := newOrderedTestSystem("collision", &log)
+		physics := newOrderedTestSystem("physics", &log)
+		input := newOrderedTestSystem("input", &log)
+
+		w := akara.NewWorld(akara.NewWorldConfig().WithScheduling(akara.DeterministicScheduling))
+
+		Convey("Systems run in order of their phases", func() {
+			render.SetPhase(akara.RenderPhase)
+			input.SetPhase(akara.PreUpdatePhase)
+
+			w.AddSystem(render, true)
+			w.AddSystem(physics, true)
+			w.AddSystem(input, true)
+
+			So(w.Err(), ShouldBeNil)
+			So(w.Systems, ShouldResemble, []akara.System{input, physics, render})
+		})
+
+		Convey("Systems run in order of their ordering constraints", func() {
+			physics.Before(collision)
+			render.After(collision)
+
+			w.AddSystem(render, true)
+			w.AddSystem(collision, true)
+			w.AddSystem(physics, true)
+
+			for _, sys := range []*orderedTestSystem{render, collision, physics} {
+				sys.SetTickFrequency(0)
+			}
+
+			So(w.Update(time.Millisecond), ShouldBeNil)
+			So(log, ShouldResemble, []string{"physics", "collision", "render"})
+		})
+
+		Convey("Ordering cycles are reported as an error", func() {
+			physics.Before(collision)
+			collision.Before(physics)
+
+			So(w.TryAddSystem(physics, true), ShouldBeNil)
+
+			err := w.TryAddSystem(collision, true)
+			So(errors.Is(err, akara.ErrSystemOrder), ShouldBeTrue)
+
+			Convey("The system that would create the cycle is neither added nor initialized", func() {
+				So(w.Systems, ShouldResemble, []akara.System{physics})
+				So(collision.World, ShouldBeNil)
+			})
+
+			Convey("The error does not fail the world update", func() {
+				So(w.Err(), ShouldBeNil)
+				So(w.Update(), ShouldBeNil)
+			})
+		})
+
+		Convey("AddSystem can be chained, and passes ordering cycles to the error handler", func() {
+			physics.Before(collision)
+			collision.Before(physics)
+
+			errs := make([]error, 0)
+			cfg := akara.NewWorldConfig().
+				WithScheduling(akara.DeterministicScheduling).
+				WithErrorHandler(func(err error) { errs = append(errs, err) })
+
+			w := akara.NewWorld(cfg).
+				AddSystem(physics, true).
+				AddSystem(collision, true)
+
+			So(w.Systems, ShouldResemble, []akara.System{physics})
+			So(len(errs), ShouldEqual, 1)
+			So(errors.Is(errs[0], akara.ErrSystemOrder), ShouldBeTrue)
+			So(w.Update(), ShouldBeNil)
+		})
+
+		Convey("Ordering cycles among the configured systems are reported by NewWorld", func() {
+			physics.Before(collision)
+			collision.Before(physics)
+
+			cfg := akara.NewWorldConfig().
+				WithScheduling(akara.DeterministicScheduling).
+				With(physics).
+				With(collision)
+
+			w := akara.NewWorld(cfg)
+
+			So(errors.Is(w.Err(), akara.ErrSystemOrder), ShouldBeTrue)
+			So(len(w.Systems), ShouldEqual, 0)
+		})
+
+		Convey("A system cannot run before a system in an earlier phase", func() {
+			input.SetPhase(akara.PreUpdatePhase)
+			physics.Before(input)
+
+			So(w.TryAddSystem(input, true), ShouldBeNil)
+			So(errors.Is(w.TryAddSystem(physics, true), akara.ErrSystemOrder), ShouldBeTrue)
+		})
+	})
+}
@@ -0,0 +1,122 @@
+package tests
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/gravestench/akara"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestWorld_Snapshot(t *testing.T) {
+	codecs := map[string]akara.Codec{
+		"the default codec": akara.DefaultCodec,
+		"the JSON codec":    akara.JSONCodec{},
+	}
+
+	for description, codec := range codecs {
+		Convey("Given an ECS World with entities, saved with "+description, t, func() {
+			newWorld := func() (*akara.World, *akara.Factory[Position], *akara.Factory[Velocity], *akara.Factory[testComponent]) {
+				w := akara.NewWorld(akara.NewWorldConfig().WithCodec(codec))
+				return w, akara.Register[Position](w), akara.Register[Velocity](w), akara.Register[testComponent](w)
+			}
+
+			w, positions, velocities, tags := newWorld()
+
+			e1, e2, e3 := w.NewEntity(), w.NewEntity(), w.NewEntity()
+
+			positions.Add(e1).X = 1
+			positions.Add(e3).X = 3
+			velocities.Add(e3).Y = 30
+			tags.Add(e1)
+
+			w.RemoveEntity(e2)
+			w.Update()
+
+			buf := &bytes.Buffer{}
+			So(w.Snapshot(buf), ShouldBeNil)
+
+			Convey("The snapshot can be restored into another world", func() {
+				restored, positions, velocities, tags := newWorld()
+				movable := restored.AddSubscription(restored.NewComponentFilter().Require(&Position{}, &Velocity{}))
+
+				So(restored.Restore(buf), ShouldBeNil)
+
+				So(restored.IsAlive(e1), ShouldBeTrue)
+				So(restored.IsAlive(e2), ShouldBeFalse)
+				So(restored.IsAlive(e3), ShouldBeTrue)
+
+				p, found := positions.Get(e3)
+				So(found, ShouldBeTrue)
+				So(p.X, ShouldEqual, 3)
+
+				v, found := velocities.Get(e3)
+				So(found, ShouldBeTrue)
+				So(v.Y, ShouldEqual, 30)
+
+				_, found = tags.Get(e1)
+				So(found, ShouldBeTrue)
+
+				So(movable.GetEntities(), ShouldResemble, []akara.EID{e3})
+
+				Convey("Entity ID's are allocated exactly like in the original world", func() {
+					So(restored.NewEntity(), ShouldEqual, w.NewEntity())
+				})
+			})
+
+			Convey("Restoring replaces the existing entities", func() {
+				positions.Add(e1).X = 100
+				e4 := w.NewEntity()
+				positions.Add(e4)
+
+				So(w.Restore(buf), ShouldBeNil)
+
+				So(w.IsAlive(e4), ShouldBeFalse)
+				So(positions.Len(), ShouldEqual, 2)
+
+				p, _ := positions.Get(e1)
+				So(p.X, ShouldEqual, 1)
+			})
+
+			Convey("Restoring fails if a component is not registered", func() {
+				other := akara.NewWorld(akara.NewWorldConfig().WithCodec(codec))
+				akara.Register[Position](other)
+
+				err := other.Restore(buf)
+				So(errors.Is(err, akara.ErrUnknownComponent), ShouldBeTrue)
+			})
+		})
+	}
+}
+
+func TestWorld_RestoreInvalidSnapshot(t *testing.T) {
+	Convey("Given an ECS World with an entity", t, func() {
+		w := akara.NewWorld(akara.NewWorldConfig().WithCodec(akara.JSONCodec{}))
+		positions := akara.Register[Position](w)
+
+		e := w.NewEntity()
+		positions.Add(e).X = 1
+
+		headers := map[string]string{
+			"mismatched entity indices": `{"Version":1,"Entities":{"Generations":[0,0],"Alive":[false,true,true]}}`,
+			"a free index out of range": `{"Version":1,"Entities":{"Generations":[0,0],"Alive":[false,true],"Free":[5]}}`,
+			"a live reserved index":     `{"Version":1,"Entities":{"Generations":[0,0],"Alive":[true,true]}}`,
+		}
+
+		for description, header := range headers {
+			Convey("Restoring a snapshot with "+description+" fails, and leaves the world untouched", func() {
+				err := w.Restore(strings.NewReader(header))
+				So(errors.Is(err, akara.ErrInvalidSnapshot), ShouldBeTrue)
+
+				So(w.IsAlive(e), ShouldBeTrue)
+
+				p, found := positions.Get(e)
+				So(found, ShouldBeTrue)
+				So(p.X, ShouldEqual, 1)
+			})
+		}
+	})
+}
@@ -0,0 +1,73 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/gravestench/akara"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestSubscription_Callbacks(t *testing.T) {
+	configs := map[string]*akara.WorldConfig{
+		"an ECS world":                        akara.NewWorldConfig(),
+		"an ECS world with archetype storage": akara.NewWorldConfig().WithArchetypeStorage(),
+	}
+
+	for description, cfg := range configs {
+		Convey("Within "+description, t, func() {
+			w := akara.NewWorld(cfg)
+
+			positions := akara.Register[Position](w)
+			velocities := akara.Register[Velocity](w)
+
+			movable := w.AddSubscription(w.NewComponentFilter().Require(&Position{}, &Velocity{}))
+
+			added, removed := make([]akara.EID, 0), make([]akara.EID, 0)
+			movable.OnEntityAdded(func(id akara.EID) { added = append(added, id) })
+			movable.OnEntityRemoved(func(id akara.EID) { removed = append(removed, id) })
+
+			e := w.NewEntity()
+			positions.Add(e)
+
+			Convey("OnEntityAdded is called when an entity starts matching", func() {
+				So(added, ShouldBeEmpty)
+
+				velocities.Add(e)
+				So(added, ShouldResemble, []akara.EID{e})
+
+				Convey("but not when a matching entity changes", func() {
+					w.UpdateEntity(e)
+					So(added, ShouldResemble, []akara.EID{e})
+				})
+
+				Convey("OnEntityRemoved is called when an entity stops matching", func() {
+					velocities.Remove(e)
+					So(removed, ShouldResemble, []akara.EID{e})
+				})
+
+				Convey("OnEntityRemoved is called when an entity is removed from the world", func() {
+					w.RemoveEntity(e)
+					So(removed, ShouldBeEmpty)
+
+					w.Update()
+					So(removed, ShouldResemble, []akara.EID{e})
+				})
+
+				Convey("OnEntityRemoved is called when an entity is ignored", func() {
+					movable.IgnoreEntity(e)
+					So(removed, ShouldResemble, []akara.EID{e})
+				})
+			})
+
+			Convey("The callbacks can use the world", func() {
+				movable.OnEntityAdded(func(id akara.EID) { positions.Remove(id) })
+
+				velocities.Add(e)
+				So(added, ShouldResemble, []akara.EID{e})
+				So(removed, ShouldResemble, []akara.EID{e})
+				So(movable.GetEntities(), ShouldBeEmpty)
+			})
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package tests
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gravestench/akara"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+var errTestSystemFailed = errors.New("test system failed")
+
+type fallibleTestSystem struct {
+	*orderedTestSystem
+	err error
+}
+
+func (sys *fallibleTestSystem) UpdateE() error {
+	sys.orderedTestSystem.Update()
+
+	return sys.err
+}
+
+func TestWorld_SystemErrors(t *testing.T) {
+	newWorld := func(policy akara.ErrorPolicy, log *[]string) (*akara.World, *fallibleTestSystem, *orderedTestSystem) {
+		a := &fallibleTestSystem{orderedTestSystem: newOrderedTestSystem("a", log)}
+		b := newOrderedTestSystem("b", log)
+
+		cfg := akara.NewWorldConfig().
+			WithScheduling(akara.DeterministicScheduling).
+			WithErrorPolicy(policy).
+			With(a).
+			With(b)
+
+		w := akara.NewWorld(cfg)
+
+		a.SetTickFrequency(0)
+		b.SetTickFrequency(0)
+
+		return w, a, b
+	}
+
+	Convey("Given an ECS World with a fallible system", t, func() {
+		log := make([]string, 0)
+		w, a, b := newWorld(akara.ContinueOnError, &log)
+
+		Convey("World Update returns nil while the system succeeds", func() {
+			So(w.Update(time.Millisecond), ShouldBeNil)
+			So(log, ShouldResemble, []string{"a", "b"})
+		})
+
+		Convey("World Update returns the error of the system, with the name of the system", func() {
+			a.err = errTestSystemFailed
+			err := w.Update(time.Millisecond)
+
+			So(err, ShouldNotBeNil)
+			So(errors.Is(err, errTestSystemFailed), ShouldBeTrue)
+
+			errs, ok := err.(akara.SystemErrors)
+			So(ok, ShouldBeTrue)
+			So(len(errs), ShouldEqual, 1)
+			So(errs[0].Name, ShouldEqual, "a")
+			So(errs[0].System, ShouldEqual, a)
+			So(err.Error(), ShouldEqual, "system a: test system failed")
+
+			Convey("Errors are only returned by the update they occurred in", func() {
+				a.err = nil
+				So(w.Update(time.Millisecond), ShouldBeNil)
+			})
+
+			Convey("With ContinueOnError, the system keeps ticking", func() {
+				So(a.Active(), ShouldBeTrue)
+				So(w.Update(time.Millisecond), ShouldNotBeNil)
+				So(log, ShouldResemble, []string{"a", "b", "a", "b"})
+			})
+		})
+
+		Convey("With DeactivateOnError, the failing system is deactivated", func() {
+			w, a, b = newWorld(akara.DeactivateOnError, &log)
+			a.err = errTestSystemFailed
+
+			So(w.Update(time.Millisecond), ShouldNotBeNil)
+			So(a.Active(), ShouldBeFalse)
+			So(b.Active(), ShouldBeTrue)
+
+			So(w.Update(time.Millisecond), ShouldBeNil)
+			So(log, ShouldResemble, []string{"a", "b", "b"})
+		})
+
+		Convey("With HaltOnError, the world is halted", func() {
+			w, a, b = newWorld(akara.HaltOnError, &log)
+			a.err = errTestSystemFailed
+
+			So(errors.Is(w.Update(time.Millisecond), errTestSystemFailed), ShouldBeTrue)
+			So(w.Halted(), ShouldBeTrue)
+			So(a.Active(), ShouldBeFalse)
+			So(b.Active(), ShouldBeFalse)
+			So(log, ShouldResemble, []string{"a"})
+
+			So(w.Update(time.Millisecond), ShouldEqual, akara.ErrWorldHalted)
+			So(log, ShouldResemble, []string{"a"})
+
+			Convey("Step and Update still apply commands and deliver deferred events", func() {
+				positions := akara.Register[Position](w)
+
+				events := 0
+				positions.Observe(akara.DeferredDelivery, func(akara.ComponentEvent) {
+					events++
+				})
+
+				for _, update := range []func() error{
+					func() error { return w.Update(time.Millisecond) },
+					func() error { return w.Step(time.Millisecond) },
+				} {
+					e := w.NewEntity()
+					w.Commands().Add(e, &Position{})
+
+					So(update(), ShouldEqual, akara.ErrWorldHalted)
+
+					_, found := positions.Get(e)
+					So(found, ShouldBeTrue)
+				}
+
+				So(events, ShouldEqual, 2)
+				So(log, ShouldResemble, []string{"a"})
+			})
+		})
+	})
+}
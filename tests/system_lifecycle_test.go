@@ -0,0 +1,133 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gravestench/akara"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type hookedTestSystem struct {
+	*orderedTestSystem
+	positions *akara.Subscription
+}
+
+func newHookedTestSystem(name string, log *[]string) *hookedTestSystem {
+	return &hookedTestSystem{orderedTestSystem: newOrderedTestSystem(name, log)}
+}
+
+func (sys *hookedTestSystem) Init(_ *akara.World) {
+	sys.positions = sys.AddSubscription(sys.NewComponentFilter().Require(&Position{}))
+}
+
+func (sys *hookedTestSystem) OnAdded(_ *akara.World) {
+	*sys.log = append(*sys.log, sys.name+".OnAdded")
+}
+
+func (sys *hookedTestSystem) OnRemoved(_ *akara.World) {
+	*sys.log = append(*sys.log, sys.name+".OnRemoved")
+}
+
+func (sys *hookedTestSystem) OnActivate() {
+	*sys.log = append(*sys.log, sys.name+".OnActivate")
+}
+
+func (sys *hookedTestSystem) OnDeactivate() {
+	*sys.log = append(*sys.log, sys.name+".OnDeactivate")
+}
+
+func TestWorld_SystemLifecycleHooks(t *testing.T) {
+	Convey("Given an ECS World with deterministic scheduling", t, func() {
+		log := make([]string, 0)
+
+		w := akara.NewWorld(akara.NewWorldConfig().WithScheduling(akara.DeterministicScheduling))
+		a := newHookedTestSystem("a", &log)
+
+		Convey("OnAdded is called when the system is added", func() {
+			w.AddSystem(a, true)
+			So(log, ShouldResemble, []string{"a.OnAdded"})
+
+			Convey("OnActivate is called when the system is activated", func() {
+				w.Update(time.Second)
+				So(log, ShouldResemble, []string{"a.OnAdded", "a.OnActivate", "a"})
+			})
+		})
+
+		Convey("Activation hooks are only called when the activation changes", func() {
+			w.AddSystem(a, false)
+
+			a.Deactivate()
+			a.Activate()
+			a.Activate()
+			a.Deactivate()
+			a.Deactivate()
+
+			So(log, ShouldResemble, []string{"a.OnAdded", "a.OnActivate", "a.OnDeactivate"})
+		})
+
+		Convey("When the system is removed", func() {
+			w.AddSystem(a, true)
+			w.Update(time.Millisecond)
+
+			log = log[:0]
+			w.RemoveSystem(a)
+			w.Update(time.Millisecond)
+
+			Convey("The system is deactivated, and OnRemoved is called", func() {
+				So(log, ShouldResemble, []string{"a.OnDeactivate", "a.OnRemoved"})
+			})
+
+			Convey("The subscriptions of the system are released", func() {
+				So(w.Subscriptions, ShouldNotContain, a.positions)
+			})
+
+			Convey("The system is only removed once", func() {
+				w.Update(time.Millisecond)
+				So(log, ShouldResemble, []string{"a.OnDeactivate", "a.OnRemoved"})
+			})
+		})
+
+		Convey("Subscriptions shared by systems are released once no system uses them", func() {
+			b := newHookedTestSystem("b", &log)
+
+			w.AddSystem(a, true)
+			w.AddSystem(b, true)
+			So(b.positions, ShouldEqual, a.positions)
+
+			w.RemoveSystem(a)
+			w.Update(time.Millisecond)
+			So(w.Subscriptions, ShouldContain, a.positions)
+
+			w.RemoveSystem(b)
+			w.Update(time.Millisecond)
+			So(w.Subscriptions, ShouldNotContain, a.positions)
+		})
+	})
+
+	Convey("Within an ECS world with archetype storage enabled", t, func() {
+		w := akara.NewWorld(akara.NewWorldConfig().WithArchetypeStorage())
+
+		positions := akara.Register[Position](w)
+		velocities := akara.Register[Velocity](w)
+
+		positioned := w.AddSubscription(w.NewComponentFilter().Require(&Position{}))
+		movable := w.AddSubscription(w.NewComponentFilter().Require(&Position{}, &Velocity{}))
+
+		e := w.NewEntity()
+		positions.Add(e)
+		velocities.Add(e)
+
+		Convey("Removed subscriptions are no longer updated", func() {
+			So(w.RemoveSubscription(positioned), ShouldBeTrue)
+
+			e2 := w.NewEntity()
+			positions.Add(e2)
+			velocities.Add(e2)
+
+			So(positioned.GetEntities(), ShouldResemble, []akara.EID{e})
+			So(movable.GetEntities(), ShouldResemble, []akara.EID{e, e2})
+		})
+	})
+}
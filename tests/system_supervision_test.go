@@ -0,0 +1,170 @@
+package tests
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gravestench/akara"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type panickingTestSystem struct {
+	*orderedTestSystem
+	panics bool
+	inits  int
+}
+
+func (sys *panickingTestSystem) Init(_ *akara.World) {
+	sys.inits++
+}
+
+func (sys *panickingTestSystem) Update() {
+	sys.orderedTestSystem.Update()
+
+	if sys.panics {
+		panic("test system panicked")
+	}
+}
+
+// restartPanickingTestSystem panics when it is initialized again
+type restartPanickingTestSystem struct {
+	*panickingTestSystem
+}
+
+func (sys *restartPanickingTestSystem) Init(w *akara.World) {
+	sys.panickingTestSystem.Init(w)
+
+	if sys.inits > 1 {
+		panic("test system panicked while restarting")
+	}
+}
+
+func TestWorld_SystemSupervision(t *testing.T) {
+	Convey("Given an ECS World with a panicking system", t, func() {
+		log := make([]string, 0)
+		handled := make([]error, 0)
+		clock := akara.NewManualClock(time.Now())
+
+		sys := &panickingTestSystem{orderedTestSystem: newOrderedTestSystem("a", &log), panics: true}
+
+		cfg := akara.NewWorldConfig().
+			WithScheduling(akara.DeterministicScheduling).
+			WithClock(clock).
+			WithErrorHandler(func(err error) { handled = append(handled, err) }).
+			With(sys)
+
+		w := akara.NewWorld(cfg)
+		sys.SetTickFrequency(0)
+
+		Convey("By default, the panic is recovered and returned by the world update", func() {
+			err := w.Update(time.Millisecond)
+
+			var panicErr *akara.PanicError
+			So(errors.As(err, &panicErr), ShouldBeTrue)
+			So(panicErr.Value, ShouldEqual, "test system panicked")
+			So(len(panicErr.Stack), ShouldBeGreaterThan, 0)
+
+			So(len(handled), ShouldEqual, 1)
+			So(sys.Active(), ShouldBeTrue)
+		})
+
+		Convey("With DeactivateOnPanic, the system is deactivated", func() {
+			sys.SetSupervision(akara.DeactivateOnPanic)
+
+			So(w.Update(time.Millisecond), ShouldBeNil)
+			So(len(handled), ShouldEqual, 1)
+			So(sys.Active(), ShouldBeFalse)
+		})
+
+		Convey("With RestartOnPanic, a panic while restarting schedules another restart", func() {
+			log := make([]string, 0)
+			restarting := &restartPanickingTestSystem{
+				panickingTestSystem: &panickingTestSystem{orderedTestSystem: newOrderedTestSystem("a", &log), panics: true},
+			}
+
+			w := akara.NewWorld(akara.NewWorldConfig().
+				WithScheduling(akara.DeterministicScheduling).
+				WithClock(clock).
+				WithErrorHandler(func(err error) { handled = append(handled, err) }).
+				With(restarting))
+
+			restarting.SetTickFrequency(0)
+			restarting.SetSupervision(akara.RestartOnPanic)
+			restarting.SetRestartBackoff(100*time.Millisecond, time.Second)
+
+			So(w.Update(time.Millisecond), ShouldBeNil)
+
+			clock.Advance(100 * time.Millisecond)
+			So(w.Update(time.Millisecond), ShouldBeNil)
+
+			So(len(handled), ShouldEqual, 2)
+			So(restarting.Restarting(), ShouldBeTrue)
+			So(log, ShouldResemble, []string{"a"})
+		})
+
+		Convey("With RestartOnPanic, the system restarts after a backoff period", func() {
+			sys.SetSupervision(akara.RestartOnPanic)
+			sys.SetRestartBackoff(100*time.Millisecond, time.Second)
+
+			So(sys.inits, ShouldEqual, 1)
+			So(w.Update(time.Millisecond), ShouldBeNil)
+			So(len(handled), ShouldEqual, 1)
+			So(sys.Restarting(), ShouldBeTrue)
+			So(sys.TickCount(), ShouldEqual, 1)
+
+			clock.Advance(50 * time.Millisecond)
+			w.Update(time.Millisecond)
+			So(log, ShouldResemble, []string{"a"})
+			So(sys.inits, ShouldEqual, 1)
+
+			clock.Advance(50 * time.Millisecond)
+			w.Update(time.Millisecond)
+			So(log, ShouldResemble, []string{"a", "a"})
+
+			Convey("The system is initialized again, and its tick count is reset", func() {
+				So(sys.inits, ShouldEqual, 2)
+				So(sys.TickCount(), ShouldEqual, 1)
+			})
+
+			Convey("The backoff period doubles with every consecutive panic", func() {
+				clock.Advance(150 * time.Millisecond)
+				w.Update(time.Millisecond)
+				So(log, ShouldResemble, []string{"a", "a"})
+
+				clock.Advance(50 * time.Millisecond)
+				sys.panics = false
+				w.Update(time.Millisecond)
+				So(log, ShouldResemble, []string{"a", "a", "a"})
+				So(sys.Restarting(), ShouldBeFalse)
+			})
+		})
+	})
+
+	Convey("Given a panicking system which ticks in the background", t, func() {
+		handled := make(chan error, 1)
+		log := make([]string, 0)
+
+		sys := &panickingTestSystem{orderedTestSystem: newOrderedTestSystem("a", &log), panics: true}
+		sys.SetSupervision(akara.DeactivateOnPanic)
+
+		cfg := akara.NewWorldConfig().
+			WithErrorHandler(func(err error) { handled <- err }).
+			With(sys)
+
+		w := akara.NewWorld(cfg)
+		w.Update()
+
+		Convey("The panic is recovered and reported to the error handler", func() {
+			select {
+			case err := <-handled:
+				var systemErr *akara.SystemError
+				So(errors.As(err, &systemErr), ShouldBeTrue)
+				So(systemErr.Name, ShouldEqual, "a")
+			case <-time.After(time.Second):
+				So("the panic was not reported", ShouldBeEmpty)
+			}
+		})
+	})
+}
@@ -106,3 +106,60 @@ func TestSystem(t *testing.T) {
 		})
 	})
 }
+
+func TestBaseSystem_FixedTimestep(t *testing.T) {
+	Convey("Given a System with a fixed timestep, ticked by the World", t, func() {
+		log := make([]string, 0)
+		sys := newOrderedTestSystem("physics", &log)
+
+		w := akara.NewWorld(akara.NewWorldConfig().WithScheduling(akara.DeterministicScheduling))
+		w.AddSystem(sys, true)
+
+		sys.SetTickFrequency(100) // 10ms steps
+		sys.SetFixedTimestep(true)
+
+		So(sys.FixedTimestep(), ShouldBeTrue)
+
+		Convey("The System steps once for every whole tick period that has elapsed", func() {
+			w.Update(25 * time.Millisecond)
+
+			So(sys.deltas, ShouldResemble, []time.Duration{10 * time.Millisecond, 10 * time.Millisecond})
+
+			Convey("The interpolation alpha is the fraction of the next step that has elapsed", func() {
+				So(sys.InterpolationAlpha(), ShouldAlmostEqual, 0.5)
+			})
+
+			Convey("The leftover time is carried over to the next tick", func() {
+				w.Update(15 * time.Millisecond)
+
+				So(len(sys.deltas), ShouldEqual, 4)
+				So(sys.InterpolationAlpha(), ShouldAlmostEqual, 0)
+			})
+		})
+
+		Convey("The interpolation alpha changes on every update, even without a step", func() {
+			sys.SetTickFrequency(30)
+
+			alphas := make([]float64, 0)
+			for idx := 0; idx < 6; idx++ {
+				w.Update(16 * time.Millisecond)
+				alphas = append(alphas, sys.InterpolationAlpha())
+			}
+
+			for idx := 1; idx < len(alphas); idx++ {
+				So(alphas[idx], ShouldNotAlmostEqual, alphas[idx-1])
+			}
+
+			So(alphas[0], ShouldAlmostEqual, 0.48, 0.001)
+			So(len(sys.deltas), ShouldEqual, 2) // 96ms of 33.3ms steps
+		})
+
+		Convey("The number of steps per tick is capped", func() {
+			sys.SetMaxStepsPerFrame(3)
+			w.Update(time.Second + 5*time.Millisecond)
+
+			So(len(sys.deltas), ShouldEqual, 3)
+			So(sys.InterpolationAlpha(), ShouldAlmostEqual, 0.5)
+		})
+	})
+}
@@ -0,0 +1,190 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gravestench/akara"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestWorld_TimeScale(t *testing.T) {
+	Convey("Given an ECS World with deterministic scheduling", t, func() {
+		log := make([]string, 0)
+
+		a := newOrderedTestSystem("a", &log)
+		b := newOrderedTestSystem("b", &log)
+
+		cfg := akara.NewWorldConfig().
+			WithScheduling(akara.DeterministicScheduling).
+			With(a).
+			With(b)
+
+		w := akara.NewWorld(cfg)
+
+		for _, sys := range []*orderedTestSystem{a, b} {
+			sys.SetTickFrequency(0) // tick on every world update
+		}
+
+		Convey("The time scale defaults to real time", func() {
+			So(w.TimeScale(), ShouldEqual, 1)
+			So(w.Paused(), ShouldBeFalse)
+		})
+
+		Convey("Systems see the scaled time delta", func() {
+			w.SetTimeScale(0.5)
+			w.Update(16 * time.Millisecond)
+
+			So(a.deltas, ShouldResemble, []time.Duration{8 * time.Millisecond})
+		})
+
+		Convey("The time scale changes how often systems tick", func() {
+			a.SetTickFrequency(10) // once every 100ms
+			w.SetTimeScale(2)
+
+			for idx := 0; idx < 10; idx++ {
+				w.Update(10 * time.Millisecond)
+			}
+
+			So(a.TickCount(), ShouldEqual, 2)
+		})
+
+		Convey("Systems do not tick when the time scale is 0", func() {
+			w.SetTimeScale(0)
+			w.Update(time.Millisecond)
+
+			So(log, ShouldBeEmpty)
+		})
+
+		Convey("A system can override the time scale of the world", func() {
+			w.SetTimeScale(0)
+			b.SetTimeScale(1)
+			w.Update(time.Millisecond)
+
+			So(log, ShouldResemble, []string{"b"})
+
+			Convey("The override can be cleared", func() {
+				b.ClearTimeScale()
+				w.Update(time.Millisecond)
+
+				So(log, ShouldResemble, []string{"b"})
+			})
+		})
+
+		Convey("When the world is paused", func() {
+			b.SetTimeScale(1)
+			w.Pause()
+
+			Convey("No systems tick, including systems which override the time scale", func() {
+				w.Update(time.Millisecond)
+
+				So(w.Paused(), ShouldBeTrue)
+				So(log, ShouldBeEmpty)
+			})
+
+			Convey("The world can be single-stepped", func() {
+				So(w.Step(16*time.Millisecond), ShouldBeNil)
+
+				So(log, ShouldResemble, []string{"a", "b"})
+				So(a.deltas, ShouldResemble, []time.Duration{16 * time.Millisecond})
+			})
+
+			Convey("Systems tick again once the world is resumed", func() {
+				w.Resume()
+				w.Update(time.Millisecond)
+
+				So(log, ShouldResemble, []string{"a", "b"})
+			})
+		})
+	})
+}
+
+func TestBaseSystem_TimeScale(t *testing.T) {
+	Convey("Given an active background System in a World with a manual clock", t, func() {
+		clock := akara.NewManualClock(time.Now())
+		ticked := make(chan time.Duration, 1)
+
+		sys := &MyTestSystem{}
+		sys.SetPostTickCallback(func() {
+			select {
+			case ticked <- sys.TimeDelta:
+			default:
+			}
+		})
+
+		w := akara.NewWorld(akara.NewWorldConfig().WithClock(clock).With(sys))
+		w.Update()
+
+		// the system creates its ticker in its own goroutine, so keep advancing until it ticks
+		waitForTick := func() time.Duration {
+			for {
+				clock.Advance(sys.TickPeriod())
+
+				select {
+				case delta := <-ticked:
+					return delta
+				case <-time.After(time.Millisecond):
+				}
+			}
+		}
+
+		Convey("The System does not tick while the World is paused", func() {
+			So(waitForTick(), ShouldBeGreaterThan, 0)
+
+			w.Pause()
+
+			// let the system see that the world is paused
+			for idx := 0; idx < 5; idx++ {
+				clock.Advance(sys.TickPeriod())
+				time.Sleep(time.Millisecond)
+			}
+
+			select {
+			case <-ticked:
+			default:
+			}
+
+			for idx := 0; idx < 5; idx++ {
+				clock.Advance(sys.TickPeriod())
+				time.Sleep(time.Millisecond)
+			}
+
+			So(len(ticked), ShouldEqual, 0)
+
+			Convey("The System ticks again once the World is resumed", func() {
+				w.Resume()
+
+				So(waitForTick(), ShouldBeGreaterThan, 0)
+
+				sys.Deactivate()
+				clock.Advance(sys.TickPeriod() * 2)
+			})
+		})
+	})
+}
+
+func TestWorld_StepWithBackgroundScheduling(t *testing.T) {
+	Convey("Given a paused ECS World whose systems tick in the background", t, func() {
+		log := make([]string, 0)
+		a := newOrderedTestSystem("a", &log)
+
+		w := akara.NewWorld()
+		So(w.TryAddSystem(a, true), ShouldBeNil)
+		a.SetTickFrequency(1000)
+
+		w.Pause()
+		So(w.Update(), ShouldBeNil)
+
+		Convey("The world can be single-stepped while the background ticker runs", func() {
+			for idx := 0; idx < 5; idx++ {
+				So(w.Step(time.Millisecond), ShouldBeNil)
+				time.Sleep(time.Millisecond)
+			}
+
+			a.Deactivate()
+
+			So(len(a.deltas), ShouldEqual, 5)
+		})
+	})
+}
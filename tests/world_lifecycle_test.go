@@ -0,0 +1,189 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gravestench/akara"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+var errTestSystemClose = errors.New("test system close failed")
+
+type destroyableTestSystem struct {
+	*orderedTestSystem
+	closeErr error
+	block    chan struct{} // if set, Update waits until it is closed
+	ticked   chan struct{}
+}
+
+func newDestroyableTestSystem(name string, log *[]string) *destroyableTestSystem {
+	return &destroyableTestSystem{
+		orderedTestSystem: newOrderedTestSystem(name, log),
+		ticked:            make(chan struct{}, 1),
+	}
+}
+
+func (sys *destroyableTestSystem) Update() {
+	select {
+	case sys.ticked <- struct{}{}:
+	default:
+	}
+
+	if sys.block != nil {
+		<-sys.block
+	}
+}
+
+func (sys *destroyableTestSystem) Destroy() {
+	*sys.log = append(*sys.log, sys.name+".Destroy")
+}
+
+func (sys *destroyableTestSystem) Close() error {
+	*sys.log = append(*sys.log, sys.name+".Close")
+	return sys.closeErr
+}
+
+func TestWorld_Shutdown(t *testing.T) {
+	Convey("Given an ECS World with systems ticking in the background", t, func() {
+		log := make([]string, 0)
+		clock := akara.NewManualClock(time.Now())
+
+		a := newDestroyableTestSystem("a", &log)
+		b := newDestroyableTestSystem("b", &log)
+
+		w := akara.NewWorld(akara.NewWorldConfig().WithClock(clock).With(a).With(b))
+		w.Update()
+
+		Convey("Shutdown stops the systems, and destroys them in reverse order", func() {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+
+			So(w.Shutdown(ctx), ShouldBeNil)
+
+			So(a.Active(), ShouldBeFalse)
+			So(b.Active(), ShouldBeFalse)
+			So(log, ShouldResemble, []string{"b.Destroy", "b.Close", "a.Destroy", "a.Close"})
+
+			Convey("The world can not be updated or restarted after shutdown", func() {
+				So(w.Update(), ShouldEqual, akara.ErrWorldShutdown)
+				So(w.ShuttingDown(), ShouldBeTrue)
+
+				a.Activate()
+				So(a.Active(), ShouldBeFalse)
+			})
+
+			Convey("Systems are only destroyed once", func() {
+				So(w.Shutdown(ctx), ShouldBeNil)
+				So(len(log), ShouldEqual, 4)
+			})
+		})
+
+		Convey("Shutdown returns the errors of Close", func() {
+			a.closeErr = errTestSystemClose
+
+			err := w.Shutdown(context.Background())
+			So(errors.Is(err, errTestSystemClose), ShouldBeTrue)
+			So(err.Error(), ShouldEqual, "system a: test system close failed")
+		})
+	})
+
+	Convey("Given an ECS World with a system update in flight", t, func() {
+		log := make([]string, 0)
+
+		a := newDestroyableTestSystem("a", &log)
+		a.block = make(chan struct{})
+
+		w := akara.NewWorld(akara.NewWorldConfig().WithScheduling(akara.DeterministicScheduling).With(a))
+		a.SetTickFrequency(0)
+
+		updated := make(chan error, 1)
+		go func() { updated <- w.Update(time.Millisecond) }()
+		<-a.ticked
+
+		Convey("Shutdown times out while the update is in flight", func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+			defer cancel()
+
+			So(errors.Is(w.Shutdown(ctx), context.DeadlineExceeded), ShouldBeTrue)
+			So(log, ShouldBeEmpty)
+
+			Convey("Shutdown succeeds once the update has finished", func() {
+				close(a.block)
+				So(<-updated, ShouldBeNil)
+
+				So(w.Shutdown(context.Background()), ShouldBeNil)
+				So(log, ShouldResemble, []string{"a.Destroy", "a.Close"})
+			})
+		})
+	})
+}
+
+type shutdownTestSystem struct {
+	akara.BaseSystem
+	err error
+}
+
+func (sys *shutdownTestSystem) Update() {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	sys.err = sys.World.Shutdown(ctx)
+}
+
+func TestWorld_ShutdownFromSystem(t *testing.T) {
+	Convey("Given an ECS World with a system which shuts down the world", t, func() {
+		sys := &shutdownTestSystem{}
+
+		w := akara.NewWorld(akara.NewWorldConfig().WithScheduling(akara.DeterministicScheduling).With(sys))
+		sys.SetTickFrequency(0)
+
+		Convey("Shutdown times out, because it waits for the update that it was called from", func() {
+			So(w.Update(time.Millisecond), ShouldBeNil)
+			So(errors.Is(sys.err, context.DeadlineExceeded), ShouldBeTrue)
+
+			Convey("The world is shutting down, and Shutdown succeeds once the update has finished", func() {
+				So(w.ShuttingDown(), ShouldBeTrue)
+				So(sys.Active(), ShouldBeFalse)
+				So(w.Shutdown(context.Background()), ShouldBeNil)
+			})
+		})
+	})
+}
+
+func TestWorld_Run(t *testing.T) {
+	Convey("Given a running ECS World", t, func() {
+		log := make([]string, 0)
+		clock := akara.NewManualClock(time.Now())
+
+		a := newDestroyableTestSystem("a", &log)
+
+		cfg := akara.NewWorldConfig().
+			WithClock(clock).
+			WithScheduling(akara.DeterministicScheduling).
+			With(a)
+
+		w := akara.NewWorld(cfg)
+		a.SetTickFrequency(0)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		stopped := make(chan error, 1)
+		go func() { stopped <- w.Run(ctx) }()
+		<-a.ticked
+
+		Convey("Run returns when the context is canceled", func() {
+			cancel()
+			So(<-stopped, ShouldEqual, context.Canceled)
+		})
+
+		Convey("Run returns when the world is shut down", func() {
+			So(w.Shutdown(context.Background()), ShouldBeNil)
+			So(<-stopped, ShouldEqual, akara.ErrWorldShutdown)
+		})
+	})
+}
@@ -94,6 +94,45 @@ func TestWorld_RemoveEntity(t *testing.T) {
 			So(found, ShouldBeFalse)
 		})
 
+		Convey("An entity which is removed has all of its components destroyed", func() {
+			cid := w.RegisterComponent(&testComponent{})
+			testComponentFactory := &testComponentFactory{ComponentFactory: w.GetComponentFactory(cid)}
+
+			sub := w.AddSubscription(w.NewComponentFilter().Require(&testComponent{}).Build())
+
+			e := w.NewEntity()
+			testComponentFactory.Add(e)
+
+			w.RemoveEntity(e)
+			w.Update()
+
+			_, found := testComponentFactory.ComponentFactory.Get(e)
+			So(found, ShouldBeFalse)
+
+			Convey("The removed entity does not reappear in subscriptions", func() {
+				other := w.NewEntity()
+				testComponentFactory.Add(other)
+
+				So(sub.GetEntities(), ShouldResemble, []akara.EID{other})
+			})
+		})
+
+		Convey("Entity removal can be observed", func() {
+			removed := make([]akara.EID, 0)
+			w.OnEntityRemoved(func(id akara.EID) {
+				removed = append(removed, id)
+			})
+
+			e := w.NewEntity()
+
+			w.RemoveEntity(e)
+			w.RemoveEntity(e)
+			w.Update()
+			w.Update()
+
+			So(removed, ShouldResemble, []akara.EID{e})
+		})
+
 		Convey("Entities that are ignored by a subscription are not returned by the subscription", func() {
 			cid := w.RegisterComponent(&testComponent{})
 			testComponentFactory := &testComponentFactory{ComponentFactory: w.GetComponentFactory(cid)}
@@ -119,6 +158,48 @@ func TestWorld_RemoveEntity(t *testing.T) {
 	})
 }
 
+func TestWorld_IsAlive(t *testing.T) {
+	Convey("For a given ECS world", t, func() {
+		w := akara.NewWorld()
+		cid := w.RegisterComponent(&testComponent{})
+		cf := w.GetComponentFactory(cid)
+
+		e := w.NewEntity()
+
+		Convey("A new entity is alive", func() {
+			So(w.IsAlive(e), ShouldBeTrue)
+		})
+
+		Convey("An entity ID that was never created is not alive", func() {
+			So(w.IsAlive(0), ShouldBeFalse)
+			So(w.IsAlive(e+1), ShouldBeFalse)
+		})
+
+		Convey("A removed entity is no longer alive", func() {
+			w.RemoveEntity(e)
+			w.Update()
+
+			So(w.IsAlive(e), ShouldBeFalse)
+
+			Convey("The index of a removed entity is recycled with a new generation", func() {
+				recycled := w.NewEntity()
+
+				So(akara.EntityIndex(recycled), ShouldEqual, akara.EntityIndex(e))
+				So(akara.EntityGeneration(recycled), ShouldEqual, akara.EntityGeneration(e)+1)
+				So(w.IsAlive(recycled), ShouldBeTrue)
+				So(w.IsAlive(e), ShouldBeFalse)
+			})
+
+			Convey("A stale entity ID cannot be given components", func() {
+				So(cf.Add(e), ShouldBeNil)
+
+				_, found := cf.Get(e)
+				So(found, ShouldBeFalse)
+			})
+		})
+	})
+}
+
 type testComponentFactory struct {
 	*akara.ComponentFactory
 }
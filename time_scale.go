@@ -0,0 +1,146 @@
+package akara
+
+import (
+	"sync"
+	"time"
+)
+
+// timeScaling holds the time scale of a world, and whether the world is paused.
+// This is read by the goroutines of background systems, so it has its own mutex.
+type timeScaling struct {
+	mutex     sync.RWMutex
+	timeScale float64
+	paused    bool
+}
+
+// timeScaler describes a System which declares its own time scale
+type timeScaler interface {
+	TimeScale() float64
+}
+
+// SetTimeScale sets how fast time passes for the systems of the world. A time scale of 1 is
+// real time, 0.5 is slow motion, 2 is fast-forward, and 0 stops time. The time scale affects
+// both the TimeDelta seen by systems and how often they tick. Negative values are treated as 0.
+//
+// Systems can override the time scale of the world; see BaseSystem.SetTimeScale.
+func (w *World) SetTimeScale(scale float64) {
+	if scale < 0 {
+		scale = 0
+	}
+
+	w.timeScaling.mutex.Lock()
+	defer w.timeScaling.mutex.Unlock()
+
+	w.timeScaling.timeScale = scale
+}
+
+// TimeScale returns the time scale of the world. This does not take Pause into account.
+func (w *World) TimeScale() float64 {
+	if w == nil || w.systemManagement == nil || w.timeScaling == nil {
+		return 1
+	}
+
+	w.timeScaling.mutex.RLock()
+	defer w.timeScaling.mutex.RUnlock()
+
+	return w.timeScaling.timeScale
+}
+
+// Pause stops time for every system of the world, including systems which override the
+// time scale of the world. Paused systems do not tick, but they remain active.
+// Use Step to single-step the systems while the world is paused.
+func (w *World) Pause() {
+	w.setPaused(true)
+}
+
+// Resume resumes the world after Pause. The time that passed while the world was paused
+// is not seen by the systems.
+func (w *World) Resume() {
+	w.setPaused(false)
+}
+
+func (w *World) setPaused(paused bool) {
+	w.timeScaling.mutex.Lock()
+	defer w.timeScaling.mutex.Unlock()
+
+	w.timeScaling.paused = paused
+}
+
+// Paused returns true if the world has been paused with Pause
+func (w *World) Paused() bool {
+	if w == nil || w.systemManagement == nil || w.timeScaling == nil {
+		return false
+	}
+
+	w.timeScaling.mutex.RLock()
+	defer w.timeScaling.mutex.RUnlock()
+
+	return w.timeScaling.paused
+}
+
+// Step activates queued systems, steps every active system exactly once with the given time
+// delta, and then removes queued systems and entities, like Update. The time delta is not
+// scaled, and systems are stepped even while the world is paused, which makes Step useful
+// for debugging. Systems with a fixed timestep are stepped by their tick period instead.
+//
+// When the world uses BackgroundScheduling, each system is stepped while holding the same lock
+// as its background ticker, so Step is best used while the world is paused.
+func (w *World) Step(timeDelta time.Duration) error {
+	if !w.lifecycle.begin() {
+		return ErrWorldShutdown
+	}
+
+	defer w.lifecycle.end()
+
+	if err := w.beginFrame(); err != nil {
+		return err
+	}
+
+	if w.Halted() {
+		return w.finishFrame()
+	}
+
+	w.processSystemStartQueue()
+
+	w.mutex.Lock()
+	systems := make([]System, len(w.Systems))
+	copy(systems, w.Systems)
+	w.mutex.Unlock()
+
+	for _, s := range systems {
+		if !s.Active() || w.Halted() {
+			continue
+		}
+
+		if baseContainer, ok := s.(hasBaseSystem); ok {
+			baseContainer.base().singleStep(timeDelta)
+			continue
+		}
+
+		s.Tick()
+	}
+
+	return w.finishFrame()
+}
+
+// systemTimeScale yields the time scale of the system, which is 0 while the world is paused
+func (w *World) systemTimeScale(s System) float64 {
+	if scaler, ok := s.(timeScaler); ok {
+		return scaler.TimeScale()
+	}
+
+	if w.Paused() {
+		return 0
+	}
+
+	return w.TimeScale()
+}
+
+// scaleDuration yields the duration multiplied by the time scale
+func scaleDuration(d time.Duration, scale float64) time.Duration {
+	if scale == 1 {
+		return d
+	}
+
+	return time.Duration(float64(d) * scale)
+}
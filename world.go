@@ -1,44 +1,84 @@
 package akara
 
 import (
+	"fmt"
 	"reflect"
-	"strings"
+	"sort"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/gravestench/bitset"
 )
 
-type componentRegistry = map[string]ComponentID
+type componentRegistry = map[reflect.Type]ComponentID
+type componentNames = map[string]ComponentID
 type componentFactories = map[ComponentID]*ComponentFactory
 
 // NewWorld creates a new world instance from the given world configs
 func NewWorld(optional ...*WorldConfig) *World {
 	cfg := NewWorldConfig() // default
 
+	if optional != nil && optional[0] != nil {
+		cfg = optional[0]
+	}
+
+	// the change tick starts after zero, so that systems which have not run yet see every component
+	changeTick := uint64(1)
+
 	world := &World{
 		entityManagement: &entityManagement{
-			nextEntityID:       new(uint64),
+			entities:           newEntityAllocator(),
 			Subscriptions:      make([]*Subscription, 0),
 			entityRemovalQueue: make([]EID, 0),
 		},
 		componentManagement: &componentManagement{
-			nextFactoryID: new(uint64),
-			registry:      make(componentRegistry),
-			factories:     make(componentFactories),
+			nextFactoryID:      new(uint64),
+			changeTick:         &changeTick,
+			registry:           make(componentRegistry),
+			names:              make(componentNames),
+			factories:          make(componentFactories),
+			componentObservers: &componentObservers{},
+			codecs:             make(componentCodecs),
 		},
 		systemManagement: &systemManagement{
 			Systems:            make([]System, 0),
 			systemRemovalQueue: make([]System, 0),
+			scheduling:         cfg.scheduling,
+			clock:              cfg.clock,
+			elapsed:            make(map[System]time.Duration),
+			timeScaling:        &timeScaling{timeScale: 1},
+			errorPolicy:        cfg.errorPolicy,
+			errorHandler:       cfg.errorHandler,
+			lifecycle:          newWorldLifecycle(),
+			codec:              cfg.codec,
 		},
 	}
 
-	if optional != nil && optional[0] != nil {
-		cfg = optional[0]
+	if cfg.archetypes {
+		world.archetypes = newArchetypeTable()
 	}
 
-	for _, system := range cfg.systems {
-		world.AddSystem(system, true)
+	if cfg.rollbackFrames > 0 {
+		if world.ticksSystems() {
+			world.rollback = newRollback(cfg.rollbackFrames)
+		} else {
+			world.err = fmt.Errorf("%w: rollback requires the world to tick its systems", ErrRollbackUnavailable)
+		}
+	}
+
+	// the ordering constraints of the configured systems are checked together, so that
+	// the world is not left with only some of them
+	if _, err := sortSystems(cfg.systems); err != nil {
+		if world.err == nil {
+			world.err = err
+		}
+	} else {
+		for _, system := range cfg.systems {
+			if err := world.TryAddSystem(system, true); err != nil && world.err == nil {
+				world.err = err
+			}
+		}
 	}
 
 	for _, c := range cfg.components {
@@ -49,22 +89,44 @@ func NewWorld(optional ...*WorldConfig) *World {
 }
 
 type componentManagement struct {
-	registry      componentRegistry
-	factories     componentFactories
-	nextFactoryID *uint64
+	registry           componentRegistry
+	names              componentNames
+	factories          componentFactories
+	nextFactoryID      *uint64
+	changeTick         *uint64
+	archetypes         *archetypeTable // nil, unless archetype storage is enabled
+	componentObservers *componentObservers
+	codecs             componentCodecs
 }
 
 type entityManagement struct {
-	nextEntityID       *uint64
+	entities           *entityAllocator
 	ComponentFlags     sync.Map // map[EID]*bitset.BitSet // bitset for each entity, shows what components the entity has
 	Subscriptions      []*Subscription
 	entityRemovalQueue []EID
+	// entityRemovalCallbacks are invoked for every entity that is removed
+	entityRemovalCallbacks []func(EID)
 }
 
 type systemManagement struct {
 	Systems               []System
 	systemActivationQueue []func()
 	systemRemovalQueue    []System
+	scheduling            SchedulingMode
+	clock                 Clock
+	elapsed               map[System]time.Duration // time accumulated by each system since it last ticked
+	lastUpdate            time.Time
+	timeScaling           *timeScaling
+	errorPolicy           ErrorPolicy
+	systemErrors          SystemErrors // returned by the next world update
+	halted                bool
+	errorHandler          func(error)
+	lifecycle             *worldLifecycle
+	commands              *CommandBuffer
+	commandMutex          sync.Mutex // held while a command buffer is applied
+	codec                 Codec
+	frame                 uint64    // the number of completed world updates, accessed atomically
+	rollback              *rollback // nil, unless rollback is enabled
 }
 
 // World contains all of the Entities, Components, and Systems
@@ -75,34 +137,165 @@ type World struct {
 	// mutex locks access to various World resources to maintain thread safety.
 	// This should be locked when accessing any shared World resources, like slices and maps
 	mutex sync.Mutex
+	// err is the first error that occurred while configuring the world
+	err error
 }
 
-// RegisterComponent registers a component type, assigning and returning its component ID
+// RegisterComponent registers a component type, assigning and returning its component ID.
+// Component types are identified by their full type identity, so two distinct types
+// with the same name (from different packages, for example) will have different component ID's.
+//
+// The component is given a default name, which is the package path and type name of the component.
+// Use RegisterNamedComponent to give the component a stable name of your own choosing.
+//
+// If the default name is already claimed by a different component type, which can only happen
+// for types declared inside of functions in the same package, or when the name was given to
+// another type with RegisterNamedComponent, the component type is still registered, but it does
+// not claim the name. Such a component type cannot be looked up by name, saved in snapshots, or
+// replicated, until it is given a name with RegisterNamedComponent. Use TryRegisterComponent to
+// get an error instead.
 func (w *World) RegisterComponent(c Component) ComponentID {
-	name := strings.ToLower(reflect.TypeOf(c).Elem().Name())
-
 	w.mutex.Lock()
 	defer w.mutex.Unlock()
 
-	id, found := w.registry[name]
-	if found {
+	t := reflect.TypeOf(c)
+
+	if id, found := w.registry[t]; found {
 		return id
 	}
 
+	name := componentTypeName(t)
+	id := w.registerComponent(c, name)
+
+	if _, claimed := w.names[name]; !claimed {
+		w.names[name] = id
+	}
+
+	return id
+}
+
+// TryRegisterComponent registers a component type with its default name, like RegisterComponent.
+// An error is returned if the default name is already claimed by a different component type;
+// such a component type must be registered with RegisterNamedComponent instead.
+func (w *World) TryRegisterComponent(c Component) (ComponentID, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	t := reflect.TypeOf(c)
+
+	if id, found := w.registry[t]; found {
+		return id, nil
+	}
+
+	name := componentTypeName(t)
+
+	if other, found := w.names[name]; found {
+		const errFmt = "%w: %q is claimed by both %v and %v, use RegisterNamedComponent to give one of them another name"
+		return 0, fmt.Errorf(errFmt, ErrComponentNameConflict, name, w.factories[other].typ, t)
+	}
+
+	id := w.registerComponent(c, name)
+	w.names[name] = id
+
+	return id, nil
+}
+
+// RegisterComponentWithStorage registers a component type, like RegisterComponent, and sets
+// the storage backend that the component factory will use for the component instances.
+func (w *World) RegisterComponentWithStorage(c Component, kind StorageKind) ComponentID {
+	id := w.RegisterComponent(c)
+
+	w.GetComponentFactory(id).SetStorage(kind)
+
+	return id
+}
+
+// RegisterNamedComponent registers a component type with an explicit name, assigning and
+// returning its component ID. The name can later be used to look up the component ID, and
+// is meant to stay stable for things like serialization.
+//
+// If the component type was already registered under its default name, it is renamed.
+// An error is returned if the name is already claimed by a different component type, or if
+// the component type was already registered with a different explicit name.
+func (w *World) RegisterNamedComponent(name string, c Component) (ComponentID, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	t := reflect.TypeOf(c)
+
+	if other, found := w.names[name]; found && w.factories[other].typ != t {
+		const errFmt = "%w: %q is claimed by both %v and %v"
+		return 0, fmt.Errorf(errFmt, ErrComponentNameConflict, name, w.factories[other].typ, t)
+	}
+
+	id, found := w.registry[t]
+	if !found {
+		factory := w.factories[w.registerComponent(c, name)]
+		factory.named = true
+		w.names[name] = factory.id
+
+		return factory.id, nil
+	}
+
+	factory := w.factories[id]
+
+	if factory.name == name {
+		return factory.id, nil
+	}
+
+	if factory.named {
+		const errFmt = "%w: %v is already registered as %q, cannot rename to %q"
+		return 0, fmt.Errorf(errFmt, ErrComponentNameConflict, t, factory.name, name)
+	}
+
+	if w.names[factory.name] == factory.id {
+		delete(w.names, factory.name)
+	}
+
+	factory.name, factory.named = name, true
+	w.names[name] = factory.id
+
+	return factory.id, nil
+}
+
+// registerComponent registers a component type which is not yet registered, with the given
+// name. The name is not claimed; that is up to the caller. The world mutex must be locked by the caller.
+func (w *World) registerComponent(c Component, name string) ComponentID {
+	t := reflect.TypeOf(c)
+
 	nextId := atomic.AddUint64(w.nextFactoryID, 1)
-	factory := newComponentFactory(ComponentID(nextId))
-	factory.world = w
+	factory := newComponentFactory(w, ComponentID(nextId))
+	factory.typ = t
+	factory.name = name
 
 	factory.provider = func() Component {
 		return c.New()
 	}
 
-	w.registry[name] = factory.id
+	w.registry[t] = factory.id
 	w.factories[factory.id] = factory
 
 	return factory.id
 }
 
+// GetComponentID returns the component ID for the component registered with the given name
+func (w *World) GetComponentID(name string) (ComponentID, bool) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	id, found := w.names[name]
+
+	return id, found
+}
+
+// claimsName returns true if the component type of the factory can be looked up by its name
+func (w *World) claimsName(factory *ComponentFactory) bool {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	return w.names[factory.name] == factory.id
+}
+
 // GetComponentFactory returns the ComponentFactory for the given ComponentID
 func (w *World) GetComponentFactory(id ComponentID) *ComponentFactory {
 	w.mutex.Lock()
@@ -120,7 +313,8 @@ func (w *World) NewComponentFilter() *ComponentFilterBuilder {
 // the system is derived from the BaseSystem.
 func (w *World) initializeSystem(s System) {
 	if baseContainer, ok := s.(hasBaseSystem); ok {
-		baseContainer.base().Init(w, s.Update)
+		baseContainer.base().setOwner(s)
+		baseContainer.base().Init(w, w.systemTickFunc(s))
 	}
 
 	if initializer, ok := s.(Initializer); ok {
@@ -128,13 +322,46 @@ func (w *World) initializeSystem(s System) {
 	}
 }
 
-// AddSystem adds a system to the world. The System will become Active on the next World Update
+// AddSystem adds a system to the world. The System will become Active on the next World Update.
+// Systems which implement AddedHook are notified once they have been added.
+//
+// The Systems of the world are kept sorted by their phase and ordering constraints.
+// If adding the System would make the ordering constraints impossible to satisfy, the System
+// is neither initialized nor added, and the error is passed to the error handler of the world;
+// see WorldConfig.WithErrorHandler. Use TryAddSystem to get the error instead.
 func (w *World) AddSystem(s System, activate bool) *World {
+	if err := w.TryAddSystem(s, activate); err != nil {
+		w.handleError(err)
+	}
+
+	return w
+}
+
+// TryAddSystem adds a system to the world, like AddSystem. If adding the System would make the
+// ordering constraints impossible to satisfy, the System is neither initialized nor added, and
+// an error wrapping ErrSystemOrder is returned.
+func (w *World) TryAddSystem(s System, activate bool) error {
+	w.mutex.Lock()
+	_, err := sortSystems(append(append([]System{}, w.Systems...), s))
+	w.mutex.Unlock()
+
+	if err != nil {
+		return err
+	}
+
 	// make sure that we properly initialize the System
 	w.initializeSystem(s)
 
 	w.mutex.Lock()
-	w.Systems = append(w.Systems, s)
+
+	// the systems are sorted again, in case other systems were added during initialization
+	sorted, err := sortSystems(append(append([]System{}, w.Systems...), s))
+	if err != nil {
+		w.mutex.Unlock()
+		return err
+	}
+
+	w.Systems = sorted
 
 	// add System to activation queue.
 	// Activating the system makes it tick automatically in its own thread
@@ -145,7 +372,29 @@ func (w *World) AddSystem(s System, activate bool) *World {
 	}
 	w.mutex.Unlock()
 
-	return w
+	if hook, ok := s.(AddedHook); ok {
+		hook.OnAdded(w)
+	}
+
+	return nil
+}
+
+// Clock returns the Clock used by the world and its systems
+func (w *World) Clock() Clock {
+	if w == nil || w.systemManagement == nil || w.clock == nil {
+		return RealClock{}
+	}
+
+	return w.clock
+}
+
+// Err returns the first error that occurred while configuring the world with NewWorld, such as
+// Systems whose ordering constraints cannot be satisfied. World Update will also return this error.
+func (w *World) Err() error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	return w.err
 }
 
 // RemoveSystem queues the given system for removal
@@ -160,63 +409,200 @@ func (w *World) RemoveSystem(s System) *World {
 
 func (w *World) processSystemStartQueue() {
 	w.mutex.Lock()
-	defer w.mutex.Unlock()
+	queue := w.systemActivationQueue
+	w.systemActivationQueue = nil
+	w.mutex.Unlock()
 
-	for _, systemStartFunc := range w.systemActivationQueue {
+	// the systems are activated while unlocked, in case their hooks call back into the world
+	for _, systemStartFunc := range queue {
 		systemStartFunc()
 	}
-
-	w.systemActivationQueue = nil
 }
 
 func (w *World) processRemoveQueues() {
+	w.processSystemRemoveQueue()
+	w.processEntityRemoveQueue()
+}
+
+// processSystemRemoveQueue drains the system removal queue. Each removed system is deactivated,
+// notified with OnRemoved, and then the subscriptions it added with BaseSystem.AddSubscription
+// are released.
+func (w *World) processSystemRemoveQueue() {
 	w.mutex.Lock()
-	defer w.mutex.Unlock()
+	queue := w.systemRemovalQueue
+	w.systemRemovalQueue = make([]System, 0)
 
-	for remIdx := range w.systemRemovalQueue {
+	removed := make([]System, 0, len(queue))
+
+	for _, s := range queue {
 		for idx := range w.Systems {
-			if w.Systems[idx] == w.systemRemovalQueue[remIdx] {
+			if w.Systems[idx] == s {
 				w.Systems = append(w.Systems[:idx], w.Systems[idx+1:]...)
-				w.systemRemovalQueue[remIdx].Deactivate()
+				delete(w.elapsed, s)
+				removed = append(removed, s)
+
 				break
 			}
 		}
 	}
 
-	for _, id := range w.entityRemovalQueue {
-		for subIdx := range w.Subscriptions {
-			for entIdx := range w.Subscriptions[subIdx].entities {
-				if w.Subscriptions[subIdx].entities[entIdx] == id {
-					w.Subscriptions[subIdx].entities = append(
-						w.Subscriptions[subIdx].entities[:entIdx],
-						w.Subscriptions[subIdx].entities[entIdx+1:]...,
-					)
-					break
-				}
+	w.mutex.Unlock()
+
+	for _, s := range removed {
+		s.Deactivate()
+
+		if hook, ok := s.(RemovedHook); ok {
+			hook.OnRemoved(w)
+		}
+
+		if baseContainer, ok := s.(hasBaseSystem); ok {
+			baseContainer.base().releaseSubscriptions()
+		}
+	}
+}
+
+// processEntityRemoveQueue drains the entity removal queue, despawning each entity and
+// then invoking the entity removal callbacks for every entity that was actually removed.
+func (w *World) processEntityRemoveQueue() {
+	w.mutex.Lock()
+	queue := w.entityRemovalQueue
+	w.entityRemovalQueue = make([]EID, 0)
+	callbacks := w.entityRemovalCallbacks
+	w.mutex.Unlock()
+
+	removed := make([]EID, 0, len(queue))
+
+	for _, id := range queue {
+		despawned, events, released := w.despawn(id)
+		if despawned {
+			removed = append(removed, id)
+		}
+
+		for _, factory := range released {
+			factory.emit(ComponentRemoved, id)
+		}
+
+		events.notify()
+	}
+
+	for _, id := range removed {
+		for _, fn := range callbacks {
+			fn(id)
+		}
+	}
+}
+
+// despawn releases the entity ID, destroys all of the entity's components, and
+// removes the entity from every subscription. Yields false if the entity was not alive.
+func (w *World) despawn(id EID) (removed bool, events subscriptionEvents, released []*ComponentFactory) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if !w.entities.release(id) {
+		return false, nil, nil // the entity was already removed
+	}
+
+	if w.archetypes != nil {
+		if a := w.archetypes.archetypeOf(id); a != nil {
+			for _, cid := range a.Mask().ToIntArray() {
+				released = append(released, w.factories[ComponentID(cid)])
 			}
 		}
 
-		w.ComponentFlags.Delete(id)
+		w.archetypes.despawn(id)
 	}
+
+	for _, factory := range w.factories {
+		if factory.release(id) {
+			released = append(released, factory)
+		}
+	}
+
+	sort.Slice(released, func(i, j int) bool {
+		return released[i].id < released[j].id
+	})
+
+	for _, subscription := range w.Subscriptions {
+		subscription.mutex.Lock()
+		if subscription.RemoveEntity(id) {
+			events = events.record(subscription, id, false)
+		}
+		delete(subscription.ignoredEntities, id)
+		subscription.mutex.Unlock()
+	}
+
+	w.ComponentFlags.Delete(id)
+
+	return true, events, released
+}
+
+// OnEntityRemoved adds a callback which is invoked with the ID of every entity removed
+// from the world. The callbacks are invoked during World Update, after the entity's
+// components have been destroyed, so the given entity ID is already stale.
+func (w *World) OnEntityRemoved(fn func(EID)) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	w.entityRemovalCallbacks = append(w.entityRemovalCallbacks, fn)
 }
 
 // UpdateEntity updates the entity in the world. This causes the entity manager to
-// update all subscriptions for this entity ID.
+// update all subscriptions for this entity ID. Stale entity ID's are ignored.
 func (w *World) UpdateEntity(id EID) {
+	if !w.IsAlive(id) {
+		return
+	}
+
 	w.updateSubscriptions(id)
 }
 
-// Update iterates through all Systems and calls the update method if the system is active
-func (w *World) Update() error {
-	w.processSystemStartQueue()
+// Update activates and removes queued Systems, and removes queued entities.
+//
+// When the world uses DeterministicScheduling, Update also ticks every active System.
+// An explicit time delta can be given, which is useful for lockstep simulation, replays, and tests.
+// Otherwise, the time that has passed since the last Update is used.
+//
+// Update returns the errors of the systems which failed since the last Update, as SystemErrors.
+// See FallibleUpdater. Otherwise, Update returns the configuration error of the world, if any
+// (see Err), or ErrWorldHalted once the world has been halted.
+//
+// Once the world has been shut down, Update does nothing, and returns ErrWorldShutdown.
+func (w *World) Update(timeDelta ...time.Duration) error {
+	if !w.lifecycle.begin() {
+		return ErrWorldShutdown
+	}
+
+	defer w.lifecycle.end()
 
+	if err := w.beginFrame(); err != nil {
+		return err
+	}
+
+	if !w.Halted() {
+		w.processSystemStartQueue()
+
+		if w.ticksSystems() {
+			w.tickSystems(w.frameDelta(timeDelta))
+		}
+	}
+
+	return w.finishFrame()
+}
+
+// finishFrame applies the command buffers, removes queued systems and entities, delivers the
+// deferred component events, and then ends the frame. This is done by both Update and Step,
+// even while the world is halted.
+func (w *World) finishFrame() error {
+	w.applyCommands()
 	w.processRemoveQueues()
+	w.flushComponentEvents()
 
-	return nil
+	return w.endFrame()
 }
 
 // AddSubscription will look for an identical component filter and return an existing
 // subscription if it can. Otherwise, it creates a new subscription and returns it.
+// Subscriptions which are no longer needed can be released with RemoveSubscription.
 func (w *World) AddSubscription(input interface{}) *Subscription {
 	var s *Subscription
 	var cf *ComponentFilter
@@ -237,29 +623,43 @@ func (w *World) AddSubscription(input interface{}) *Subscription {
 		return nil
 	}
 
+	w.mutex.Lock()
+
 	for subIdx := range w.Subscriptions {
 		if w.Subscriptions[subIdx].Filter.Equals(cf) {
+			w.Subscriptions[subIdx].refs++
+			w.mutex.Unlock()
+
 			return w.Subscriptions[subIdx]
 		}
 	}
 
+	s.refs++
+	s.world = w
 	w.Subscriptions = append(w.Subscriptions, s)
 
+	w.mutex.Unlock()
+
+	if w.archetypes != nil {
+		w.addArchetypeSubscription(s)
+		return s
+	}
+
 	// need to inform new subscriptions about existing entities
 	for _, cid := range cf.Required.ToIntArray() {
-		for eid := range w.factories[ComponentID(cid)].instances {
+		for _, eid := range w.factories[ComponentID(cid)].entityIDs() {
 			w.UpdateEntity(eid)
 		}
 	}
 
 	for _, cid := range cf.OneRequired.ToIntArray() {
-		for eid := range w.factories[ComponentID(cid)].instances {
+		for _, eid := range w.factories[ComponentID(cid)].entityIDs() {
 			w.UpdateEntity(eid)
 		}
 	}
 
 	for _, cid := range cf.Forbidden.ToIntArray() {
-		for eid := range w.factories[ComponentID(cid)].instances {
+		for _, eid := range w.factories[ComponentID(cid)].entityIDs() {
 			w.UpdateEntity(eid)
 		}
 	}
@@ -267,15 +667,22 @@ func (w *World) AddSubscription(input interface{}) *Subscription {
 	return s
 }
 
-// NewEntity creates a new entity and Component BitSet
+// NewEntity creates a new entity and Component BitSet. The index of a removed entity
+// may be recycled, but the yielded entity ID will have a new generation.
 func (w *World) NewEntity() EID {
-	nextId := atomic.AddUint64(w.nextEntityID, 1)
-	w.ComponentFlags.Store(nextId, &bitset.BitSet{})
+	id := w.entities.allocate()
+	w.ComponentFlags.Store(id, &bitset.BitSet{})
 
-	return nextId
+	return id
 }
 
-// RemoveEntity removes an entity
+// IsAlive returns true if the entity ID refers to an entity which exists in the world.
+// Entity ID's of removed entities are stale, and are never alive again.
+func (w *World) IsAlive(id EID) bool {
+	return w.entities.contains(id)
+}
+
+// RemoveEntity queues an entity for removal. The entity is removed on the next World Update
 func (w *World) RemoveEntity(id EID) {
 	w.mutex.Lock()
 	defer w.mutex.Unlock()
@@ -306,15 +713,24 @@ func (w *World) updateComponentFlags(id EID) {
 // updateSubscriptions will iterate through all subscriptions and add the entity id
 // to the subscription if the entity can pass through the subscription filter
 func (w *World) updateSubscriptions(id EID) {
+	// the subscription callbacks are invoked once the world is unlocked
+	w.refreshSubscriptions(id).notify()
+}
+
+func (w *World) refreshSubscriptions(id EID) (events subscriptionEvents) {
 	w.mutex.Lock()
 	defer w.mutex.Unlock()
 
+	if w.archetypes != nil {
+		return w.updateArchetypeSubscriptions(id)
+	}
+
 	w.updateComponentFlags(id)
 
 	cfInterface, found := w.ComponentFlags.Load(id)
 	if !found {
 		// uhhhhh
-		return
+		return nil
 	}
 
 	cf := cfInterface.(*bitset.BitSet)
@@ -323,11 +739,15 @@ func (w *World) updateSubscriptions(id EID) {
 		subscription.mutex.Lock()
 
 		if subscription.Filter.Allow(cf) && !subscription.EntityIsIgnored(id) {
-			subscription.AddEntity(id)
-		} else {
-			subscription.RemoveEntity(id)
+			if subscription.AddEntity(id) {
+				events = events.record(subscription, id, true)
+			}
+		} else if subscription.RemoveEntity(id) {
+			events = events.record(subscription, id, false)
 		}
 
 		subscription.mutex.Unlock()
 	}
+
+	return events
 }
@@ -0,0 +1,133 @@
+package akara
+
+import "github.com/gravestench/bitset"
+
+// QueryArchetypes returns every archetype which is allowed by the given component filter.
+// Iterating over the entities and columns of the archetypes is the fastest way to process
+// all entities matching the filter. Yields nil if archetype storage is not enabled.
+func (w *World) QueryArchetypes(filter *ComponentFilter) []*Archetype {
+	if w.archetypes == nil {
+		return nil
+	}
+
+	return w.archetypes.query(filter)
+}
+
+// archetypeSubscriptions yields the subscriptions which allow the archetype. Subscriptions
+// are only checked against an archetype once, so this is usually just a cached lookup.
+// The world mutex must be locked by the caller.
+func (w *World) archetypeSubscriptions(a *Archetype) []*Subscription {
+	if a == nil {
+		return nil
+	}
+
+	for ; a.numChecked < len(w.Subscriptions); a.numChecked++ {
+		subscription := w.Subscriptions[a.numChecked]
+
+		if subscription.Filter.Allow(a.mask) {
+			a.subscriptions = append(a.subscriptions, subscription)
+		}
+	}
+
+	return a.subscriptions
+}
+
+// updateArchetypeSubscriptions updates the subscriptions for the entity when archetype storage
+// is enabled. Only the subscriptions of the archetype the entity left, and the archetype
+// the entity entered, are considered. The world mutex must be locked by the caller.
+func (w *World) updateArchetypeSubscriptions(id EID) (events subscriptionEvents) {
+	current := w.archetypes.archetypeOf(id)
+	previous := w.archetypes.subscribed[id]
+
+	if current == previous {
+		return nil
+	}
+
+	if current == nil {
+		delete(w.archetypes.subscribed, id)
+		w.ComponentFlags.Store(id, &bitset.BitSet{})
+	} else {
+		w.archetypes.subscribed[id] = current
+		w.ComponentFlags.Store(id, current.Mask())
+	}
+
+	entered := w.archetypeSubscriptions(current)
+
+	for _, subscription := range w.archetypeSubscriptions(previous) {
+		if containsSubscription(entered, subscription) {
+			continue
+		}
+
+		subscription.mutex.Lock()
+		if subscription.RemoveEntity(id) {
+			events = events.record(subscription, id, false)
+		}
+		subscription.mutex.Unlock()
+	}
+
+	for _, subscription := range entered {
+		subscription.mutex.Lock()
+
+		if !subscription.EntityIsIgnored(id) && subscription.AddEntity(id) {
+			events = events.record(subscription, id, true)
+		}
+
+		subscription.mutex.Unlock()
+	}
+
+	return events
+}
+
+// addArchetypeSubscription informs a new subscription about the existing entities of every
+// archetype the subscription allows.
+func (w *World) addArchetypeSubscription(s *Subscription) {
+	var events subscriptionEvents
+
+	w.mutex.Lock()
+	s.mutex.Lock()
+
+	for _, a := range w.archetypes.query(s.Filter) {
+		for _, id := range a.Entities() {
+			// only entities with up-to-date subscriptions; the others are added when they are updated
+			if w.archetypes.subscribed[id] == a && !s.EntityIsIgnored(id) && s.AddEntity(id) {
+				events = events.record(s, id, true)
+			}
+		}
+	}
+
+	s.mutex.Unlock()
+	w.mutex.Unlock()
+
+	events.notify()
+}
+
+// forgetArchetypeSubscription removes a subscription, which was at the given index of the
+// world subscriptions, from the cached subscriptions of every archetype.
+// The world mutex must be locked by the caller.
+func (w *World) forgetArchetypeSubscription(s *Subscription, idx int) {
+	w.archetypes.mutex.RLock()
+	defer w.archetypes.mutex.RUnlock()
+
+	for _, a := range w.archetypes.order {
+		if idx < a.numChecked {
+			a.numChecked--
+		}
+
+		for subIdx := range a.subscriptions {
+			if a.subscriptions[subIdx] == s {
+				a.subscriptions = append(a.subscriptions[:subIdx], a.subscriptions[subIdx+1:]...)
+				break
+			}
+		}
+	}
+}
+
+func containsSubscription(subscriptions []*Subscription, s *Subscription) bool {
+	for idx := range subscriptions {
+		if subscriptions[idx] == s {
+			return true
+		}
+	}
+
+	return false
+}
@@ -11,8 +11,15 @@ func NewWorldConfig() *WorldConfig {
 // WorldConfig is used to declare Systems and component mappers.
 // This is to be passed to a World factory function.
 type WorldConfig struct {
-	systems    []System
-	components []Component
+	systems        []System
+	components     []Component
+	archetypes     bool
+	scheduling     SchedulingMode
+	clock          Clock
+	errorPolicy    ErrorPolicy
+	errorHandler   func(error)
+	codec          Codec
+	rollbackFrames int
 }
 
 // With is used to add either Systems or component maps.
@@ -35,3 +42,64 @@ func (b *WorldConfig) With(arg interface{}) *WorldConfig {
 
 	return b
 }
+
+// WithArchetypeStorage enables archetype storage for the world. Entities with exactly the same
+// set of components are grouped into archetypes, and the components are stored column-wise
+// in each archetype. Subscriptions are then matched once per archetype, instead of once for
+// every change to an entity, and archetypes can be queried with World.QueryArchetypes.
+func (b *WorldConfig) WithArchetypeStorage() *WorldConfig {
+	b.archetypes = true
+
+	return b
+}
+
+// WithScheduling sets how the systems of the world are ticked. See SchedulingMode.
+func (b *WorldConfig) WithScheduling(mode SchedulingMode) *WorldConfig {
+	b.scheduling = mode
+
+	return b
+}
+
+// WithClock sets the Clock used by the world and its systems. By default, the world uses a
+// RealClock. A ManualClock can be used to control the passage of time in tests.
+func (b *WorldConfig) WithClock(c Clock) *WorldConfig {
+	b.clock = c
+
+	return b
+}
+
+// WithErrorPolicy sets what the world does when a System fails. See ErrorPolicy and FallibleUpdater.
+func (b *WorldConfig) WithErrorPolicy(policy ErrorPolicy) *WorldConfig {
+	b.errorPolicy = policy
+
+	return b
+}
+
+// WithErrorHandler sets a function which is called with every error of a System, including
+// recovered panics and Systems which could not be added, as soon as it occurs. The handler is called on the goroutine of the System,
+// so it must be safe for concurrent use. See SupervisionStrategy.
+func (b *WorldConfig) WithErrorHandler(fn func(error)) *WorldConfig {
+	b.errorHandler = fn
+
+	return b
+}
+
+// WithCodec sets the codec used to write and read world snapshots. By default, the world uses
+// the DefaultCodec. See World.Snapshot.
+func (b *WorldConfig) WithCodec(c Codec) *WorldConfig {
+	b.codec = c
+
+	return b
+}
+
+// WithRollback makes the world record its state after every update, keeping the given number
+// of frames, so that the world can be rewound to one of them. Rollback requires the world to
+// tick its systems; see SchedulingMode and World.Rewind.
+//
+// Only the component instances which were added or changed since the previous frame are
+// recorded, so components must be changed through GetMut or MarkChanged to be rolled back.
+func (b *WorldConfig) WithRollback(frames int) *WorldConfig {
+	b.rollbackFrames = frames
+
+	return b
+}
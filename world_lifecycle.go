@@ -0,0 +1,183 @@
+package akara
+
+import (
+	"context"
+	"io"
+	"sync"
+)
+
+// Destroyer describes a System which releases its resources when the world shuts down.
+// Systems may also implement io.Closer. See World.Shutdown.
+type Destroyer interface {
+	Destroy()
+}
+
+// worldLifecycle tracks the goroutines and updates which are in flight, so that the world
+// can be shut down gracefully. It has its own mutex, because systems are activated while
+// the world mutex is locked.
+type worldLifecycle struct {
+	mutex     sync.Mutex
+	closing   bool
+	destroyed bool
+	done      chan struct{} // closed when the world starts shutting down
+	inFlight  sync.WaitGroup
+}
+
+func newWorldLifecycle() *worldLifecycle {
+	return &worldLifecycle{
+		done: make(chan struct{}),
+	}
+}
+
+// begin registers an update or a ticking goroutine as in flight. Returns false once the
+// world is shutting down, in which case nothing should be started.
+func (l *worldLifecycle) begin() bool {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if l.closing {
+		return false
+	}
+
+	l.inFlight.Add(1)
+
+	return true
+}
+
+// end marks an update or a ticking goroutine as finished
+func (l *worldLifecycle) end() {
+	l.inFlight.Done()
+}
+
+// shutdownSignal yields a channel which is closed when the world starts shutting down
+func (w *World) shutdownSignal() <-chan struct{} {
+	if w == nil || w.systemManagement == nil || w.lifecycle == nil {
+		return nil
+	}
+
+	return w.lifecycle.done
+}
+
+// ShuttingDown returns true once Shutdown has been called
+func (w *World) ShuttingDown() bool {
+	if w == nil || w.systemManagement == nil || w.lifecycle == nil {
+		return false
+	}
+
+	w.lifecycle.mutex.Lock()
+	defer w.lifecycle.mutex.Unlock()
+
+	return w.lifecycle.closing
+}
+
+// Run updates the world repeatedly, at the DefaultTickRate, until the context is done or the
+// world is shut down. Run returns the error of the context, or ErrWorldShutdown.
+//
+// Errors returned by systems do not stop Run, unless they halt the world (see HaltOnError);
+// use WorldConfig.WithErrorHandler to observe them. Any other error of the world update,
+// such as the configuration error of the world, is returned immediately.
+//
+// Run does not shut down the world; call Shutdown once Run has returned.
+func (w *World) Run(ctx context.Context) error {
+	ticker := w.Clock().NewTicker(calculateTickPeriod(DefaultTickRate))
+	defer ticker.Stop()
+
+	for {
+		if err := w.Update(); err != nil {
+			if _, isSystemErrors := err.(SystemErrors); !isSystemErrors || w.Halted() {
+				return err
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-w.shutdownSignal():
+			return ErrWorldShutdown
+		case <-ticker.C():
+		}
+	}
+}
+
+// Shutdown stops the world. Every system is deactivated, and no more updates or ticks are
+// started. Shutdown then waits for the world updates and the system ticks which are in
+// flight, and finally calls Destroy and Close on the systems which implement Destroyer or
+// io.Closer, in the reverse order of the systems.
+//
+// If the context is done before the ticks in flight have finished, Shutdown returns the
+// error of the context without destroying the systems. Shutdown can then be called again.
+// The errors returned by Close are returned as SystemErrors.
+//
+// Shutdown waits for the world update that it is called from, so a System which calls
+// Shutdown from its Update always gets the error of the context, even though the world
+// has started shutting down. Such a System should use a context which is done quickly,
+// and Shutdown should be called again once the update has returned. Alternatively, cancel
+// the context given to Run, and call Shutdown once Run has returned.
+func (w *World) Shutdown(ctx context.Context) error {
+	w.lifecycle.mutex.Lock()
+
+	if !w.lifecycle.closing {
+		w.lifecycle.closing = true
+		close(w.lifecycle.done)
+	}
+
+	w.lifecycle.mutex.Unlock()
+
+	w.mutex.Lock()
+	systems := make([]System, len(w.Systems))
+	copy(systems, w.Systems)
+	w.mutex.Unlock()
+
+	for _, s := range systems {
+		s.Deactivate()
+	}
+
+	drained := make(chan struct{})
+
+	go func() {
+		w.lifecycle.inFlight.Wait()
+		close(drained)
+	}()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-drained:
+	}
+
+	w.lifecycle.mutex.Lock()
+	destroyed := w.lifecycle.destroyed
+	w.lifecycle.destroyed = true
+	w.lifecycle.mutex.Unlock()
+
+	if destroyed {
+		return nil
+	}
+
+	return w.destroySystems(systems)
+}
+
+// destroySystems calls the Destroy and Close hooks of the systems, in reverse order
+func (w *World) destroySystems(systems []System) error {
+	var errs SystemErrors
+
+	for idx := len(systems) - 1; idx >= 0; idx-- {
+		s := systems[idx]
+
+		if destroyer, ok := s.(Destroyer); ok {
+			destroyer.Destroy()
+		}
+
+		if closer, ok := s.(io.Closer); ok {
+			if err := closer.Close(); err != nil {
+				errs = append(errs, &SystemError{System: s, Name: s.Name(), Err: err})
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}